@@ -0,0 +1,93 @@
+package benchmark_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/safelock"
+)
+
+// size of the random (incompressible) content encrypted by the benchmarks
+const contentSize = 64 * 1024 * 1024
+
+// run with `go test ./benchmark -bench . -benchtime 5x` to compare the throughput of each workers count
+func BenchmarkEncryptWorkers(b *testing.B) {
+	content := getRandomContent(b)
+
+	for _, workers := range getWorkersCounts() {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			sl := getBenchSafelock()
+			sl.Workers = workers
+			b.SetBytes(int64(len(content)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				input := bytes.NewReader(content)
+
+				if err := sl.EncryptReader(context.TODO(), input, "content", io.Discard, "123456789"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// decrypting is measured through verifying, so extracted files aren't written
+func BenchmarkDecryptWorkers(b *testing.B) {
+	encrypted := &bytes.Buffer{}
+	content := getRandomContent(b)
+	input := bytes.NewReader(content)
+
+	if err := getBenchSafelock().EncryptReader(context.TODO(), input, "content", encrypted, "123456789"); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, workers := range getWorkersCounts() {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			sl := getBenchSafelock()
+			sl.Workers = workers
+			sl.ReadAhead = workers * 2
+			b.SetBytes(int64(len(content)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := sl.Verify(context.TODO(), bytes.NewReader(encrypted.Bytes()), "123456789"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// safelock with no compression and a cheap key derivation, so mostly the chunks encryption is measured
+func getBenchSafelock() *safelock.Safelock {
+	sl := safelock.New()
+	sl.Compression = nil
+	sl.IterationCount = 1
+	sl.MemSize = 1024
+	sl.Quiet = true
+	return sl
+}
+
+func getWorkersCounts() (counts []int) {
+	for workers := 1; workers < runtime.NumCPU(); workers *= 2 {
+		counts = append(counts, workers)
+	}
+
+	return append(counts, runtime.NumCPU())
+}
+
+func getRandomContent(b *testing.B) []byte {
+	content := make([]byte, contentSize)
+
+	if _, err := rand.Read(content); err != nil {
+		b.Fatal(err)
+	}
+
+	return content
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
+	"runtime"
 
 	"github.com/mrf345/safelock-cli/safelock"
 	"github.com/mrf345/safelock-cli/slErrs"
@@ -10,10 +13,17 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var includePaths, excludePaths, preserveAttrs []string
+var onConflict string
+var readAhead int
+
+// how many times the password can be entered before decrypting fails
+const passwordAttempts = 3
+
 var decryptCmd = &cobra.Command{
 	Use:   "decrypt",
 	Short: "decrypt [encrypted file path] [directory path]",
-	Long:  "decrypt [encrypted file path] [directory path]",
+	Long:  "decrypt [encrypted file path or - for stdin] [directory path]",
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 		var pwd string
@@ -33,28 +43,116 @@ var decryptCmd = &cobra.Command{
 		}
 
 		sl = safelock.New()
+		inputPath, outputPath := args[0], args[1]
+		inputFile, pwd = openEncryptedInput(inputPath, sl)
+		defer inputFile.Close()
 
-		if pwd, err = utils.GetPassword(sl.MinPasswordLength); err != nil {
-			utils.PrintErrsAndExit(err.Error())
+		sl.Quiet = beQuiet
+		sl.Include = includePaths
+		sl.Exclude = excludePaths
+		setPreserveAttrs(sl, preserveAttrs)
+		setWorkers(sl)
+
+		if readAhead < 1 {
+			utils.PrintErrsAndExit(fmt.Sprintf("invalid number of read ahead chunks (%d)", readAhead))
 		}
 
-		sl.Quiet = beQuiet
-		inputPath, outputPath := args[0], args[1]
+		sl.ReadAhead = readAhead
 
-		if inputFile, err = os.Open(inputPath); err != nil {
-			utils.PrintErrsAndExit((&slErrs.ErrInvalidInputPath{
-				Path: inputPath,
-				Err:  err,
-			}).Error())
+		switch policy := safelock.ConflictPolicy(onConflict); policy {
+		case safelock.ConflictOverwrite,
+			safelock.ConflictSkip,
+			safelock.ConflictRename,
+			safelock.ConflictFail,
+			safelock.ConflictNewer:
+			sl.OnConflict = policy
+		default:
+			utils.PrintErrsAndExit(
+				fmt.Sprintf("invalid conflict policy (%s)", onConflict),
+				"expected: overwrite, skip, rename, fail or newer",
+			)
 		}
-		defer inputFile.Close()
 
-		if err = sl.Decrypt(context.TODO(), inputFile, outputPath, pwd); err != nil {
+		// only prompted passwords of seekable inputs can be entered again, and only if they unlocked
+		// nothing, since corrupted files could fail after some of their content was extracted
+		canRetry := inputPath != stdPath &&
+			len(identityPaths) == 0 &&
+			!passwordSource.IsSet() &&
+			utils.IsPasswordPrompted()
+
+		for attempt := 1; ; attempt++ {
+			err = sl.Decrypt(context.TODO(), inputFile, outputPath, pwd)
+
+			if !canRetry || attempt == passwordAttempts || !slErrs.Is[*slErrs.ErrNoMatchingKey](err) {
+				break
+			}
+
+			if _, err = inputFile.Seek(0, io.SeekStart); err != nil {
+				break
+			}
+
+			fmt.Fprintf(os.Stderr, "Invalid password, attempt %d of %d\n", attempt+1, passwordAttempts)
+			pwd = readPassword(getPasswordLength(sl), false, false)
+		}
+
+		if err != nil {
 			utils.PrintErrsAndExit(err.Error())
 		}
 	},
 }
 
+func setPreserveAttrs(sl *safelock.Safelock, attrs []string) {
+	sl.PreservePermissions = false
+	sl.PreserveTimes = false
+	sl.PreserveOwnership = false
+
+	for _, attr := range attrs {
+		switch attr {
+		case "mode":
+			sl.PreservePermissions = true
+		case "timestamps":
+			sl.PreserveTimes = true
+		case "ownership":
+			sl.PreserveOwnership = true
+		case "all":
+			setPreserveAttrs(sl, []string{"mode", "timestamps", "ownership"})
+		default:
+			utils.PrintErrsAndExit(
+				fmt.Sprintf("invalid preserve attribute (%s)", attr),
+				"expected: mode, timestamps, ownership or all",
+			)
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(decryptCmd)
+	decryptCmd.Flags().StringArrayVarP(
+		&identityPaths, "identity", "i", nil, "identity file to decrypt with instead of a password",
+	)
+	decryptCmd.Flags().StringVar(
+		&keyfilePath, "keyfile", "", "keyfile to decrypt with along with the password, or instead of it",
+	)
+	decryptCmd.Flags().StringArrayVar(
+		&includePaths, "include", nil, "only extract paths matching glob pattern (e.g. 'docs/**')",
+	)
+	decryptCmd.Flags().StringArrayVar(
+		&excludePaths, "exclude", nil, "skip extracting paths matching glob pattern (e.g. '*.tmp')",
+	)
+	decryptCmd.Flags().StringSliceVar(
+		&preserveAttrs,
+		"preserve",
+		[]string{"mode", "timestamps"},
+		"files attributes to restore (mode, timestamps, ownership (as root) or all)",
+	)
+	decryptCmd.Flags().StringVar(
+		&onConflict,
+		"on-conflict",
+		"overwrite",
+		"how existing files are handled (overwrite, skip, rename, fail or newer)",
+	)
+	addWorkersFlag(decryptCmd, "number of chunks decrypted concurrently")
+	decryptCmd.Flags().IntVar(
+		&readAhead, "read-ahead", runtime.NumCPU()*2, "number of chunks decrypted ahead of extracting them",
+	)
 }
@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -11,52 +15,188 @@ import (
 	"github.com/mrf345/safelock-cli/utils"
 )
 
+var streamName string
+var linksPolicy string
+var encryptOutput string
+var encryptInclude, encryptExclude, excludeFrom []string
+var recipientKeys []string
+var encryptComment string
+var storeHostname bool
+
 var encryptCmd = &cobra.Command{
 	Use:   "encrypt",
-	Short: "encrypt [file or directory path] [encrypted file path]",
-	Long:  "encrypt [file or directory path] [encrypted file path]",
+	Short: "encrypt [file or directory paths] [encrypted file path]",
+	Long: "encrypt [file or directory paths, src:dest to rename them, or - for stdin] " +
+		"[encrypted file path or - for stdout, or use -o]",
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 		var pwd string
 		var sl *safelock.Safelock
 		var outputFile *os.File
-		const example = "example: safelock-cli encrypt test.txt encrypted.bin"
+		var inputPaths []string
+		var outputPath string
+		const example = "example: safelock-cli encrypt test.txt dir1 dir2 -o encrypted.bin"
 
-		switch len(args) {
-		case 0:
+		switch {
+		case encryptOutput != "" && len(args) > 0:
+			inputPaths, outputPath = args, encryptOutput
+		case encryptOutput != "":
+			utils.PrintErrsAndExit("missing input paths", example)
+		case len(args) == 0:
 			utils.PrintErrsAndExit("missing input and output file paths", example)
-		case 1:
+		case len(args) == 1:
 			utils.PrintErrsAndExit("missing output file path", example)
-		case 2:
-			break
+		case len(args) == 2:
+			inputPaths, outputPath = args[:1], args[1]
 		default:
-			utils.PrintErrsAndExit("too many arguments", example)
+			utils.PrintErrsAndExit("missing output file path, use -o with multiple inputs", example)
 		}
 
 		sl = safelock.New()
+		inputPath := inputPaths[0]
+		setPasswordPolicy(sl)
 
-		if pwd, err = utils.GetPassword(sl.MinPasswordLength); err != nil {
-			utils.PrintErrsAndExit(err.Error())
+		if len(inputPaths) > 1 && slices.Contains(inputPaths, stdPath) {
+			utils.PrintErrsAndExit("stdin can't be encrypted along with other paths", example)
 		}
 
-		sl.Quiet = beQuiet
-		inputPath, outputPath := []string{args[0]}, args[1]
-		fileFlags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+		sl.EncryptInclude = encryptInclude
+		sl.EncryptExclude = encryptExclude
+
+		for _, path := range excludeFrom {
+			var patterns []string
 
-		if outputFile, err = os.OpenFile(outputPath, fileFlags, 0755); err != nil {
-			utils.PrintErrsAndExit((&slErrs.ErrInvalidOutputPath{
-				Path: outputPath,
-				Err:  err,
-			}).Error())
+			if patterns, err = utils.ReadPatterns(path); err != nil {
+				utils.PrintErrsAndExit(fmt.Sprintf("failed to read exclude file (%s) > %s", path, err))
+			}
+
+			sl.EncryptExclude = append(sl.EncryptExclude, patterns...)
 		}
-		defer outputFile.Close()
 
-		if err = sl.Encrypt(context.TODO(), inputPath, outputFile, pwd); err != nil {
+		switch policy := safelock.LinksPolicy(linksPolicy); policy {
+		case safelock.LinksStore, safelock.LinksFollow, safelock.LinksSkip:
+			sl.Links = policy
+		default:
+			utils.PrintErrsAndExit(
+				fmt.Sprintf("invalid links policy (%s)", linksPolicy),
+				"expected: store, follow or skip",
+			)
+		}
+
+		setWorkers(sl)
+		sl.Metadata = getMetadata()
+		sl.Recipients = parseRecipients(recipientKeys)
+		minPasswordLength := loadKeyfile(sl)
+
+		// recipients decrypt with their identities, so no password is needed
+		if len(sl.Recipients) == 0 {
+			// stdin is used for the data, so the password has to come from elsewhere,
+			// and it's confirmed since a typo would make the output undecryptable
+			pwd = readPassword(minPasswordLength, inputPath == stdPath, true)
+		}
+
+		// stdout is used for the data, so no logs
+		sl.Quiet = beQuiet || outputPath == stdPath
+
+		if outputPath == stdPath {
+			outputFile = os.Stdout
+		} else {
+			fileFlags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+
+			if outputFile, err = os.OpenFile(outputPath, fileFlags, 0755); err != nil {
+				utils.PrintErrsAndExit((&slErrs.ErrInvalidOutputPath{
+					Path: outputPath,
+					Err:  err,
+				}).Error())
+			}
+			defer outputFile.Close()
+		}
+
+		if inputPath == stdPath {
+			err = sl.EncryptReader(context.TODO(), os.Stdin, streamName, outputFile, pwd)
+		} else {
+			err = sl.EncryptNamed(context.TODO(), getNamedPaths(inputPaths), outputFile, pwd)
+		}
+
+		if err != nil {
 			utils.PrintErrsAndExit(err.Error())
 		}
 	},
 }
 
+// maps `src:dest` paths to their names within the encrypted file, paths that exist are kept as is
+// since they might contain colons (e.g. C:\ on windows), sources and names can only be used once
+// except for names ending with a slash, which are directories paths can be placed within
+func getNamedPaths(paths []string) (named map[string]string) {
+	named = make(map[string]string, len(paths))
+	names := make(map[string]string, len(paths))
+
+	for _, path := range paths {
+		src, dest := path, ""
+		idx := strings.LastIndex(path, ":")
+
+		if _, err := os.Stat(path); err != nil && idx != -1 {
+			src, dest = path[:idx], path[idx+1:]
+		}
+
+		if _, ok := named[src]; ok {
+			utils.PrintErrsAndExit(fmt.Sprintf("duplicate input path (%s)", src))
+		}
+
+		if other, ok := names[dest]; ok && dest != "" && !strings.HasSuffix(dest, "/") {
+			utils.PrintErrsAndExit(fmt.Sprintf("duplicate encrypted name (%s) of (%s) and (%s)", dest, other, src))
+		}
+
+		named[src] = dest
+		names[dest] = src
+	}
+
+	return
+}
+
 func init() {
 	rootCmd.AddCommand(encryptCmd)
+	encryptCmd.Flags().StringVarP(&encryptOutput, "output", "o", "", "encrypted file path or - for stdout")
+	encryptCmd.Flags().StringArrayVarP(
+		&recipientKeys, "recipient", "r", nil, "public key to encrypt to instead of a password (see keygen)",
+	)
+	encryptCmd.Flags().StringVar(&streamName, "name", "stdin", "name of the file encrypted from stdin")
+	encryptCmd.Flags().StringVar(
+		&keyfilePath, "keyfile", "", "file to use as key along with the password, or instead of it if left empty",
+	)
+	encryptCmd.Flags().StringArrayVar(
+		&encryptInclude, "include", nil, "only encrypt paths matching glob pattern (e.g. 'src/**')",
+	)
+	encryptCmd.Flags().StringArrayVar(
+		&encryptExclude, "exclude", nil, "skip encrypting paths matching glob pattern (e.g. 'node_modules')",
+	)
+	encryptCmd.Flags().StringArrayVar(
+		&excludeFrom, "exclude-from", nil, "skip encrypting paths matching glob patterns listed in file",
+	)
+	encryptCmd.Flags().StringVar(&linksPolicy, "links", "store", "how symbolic links are encrypted (store, follow or skip)")
+	addPasswordPolicyFlag(encryptCmd)
+	encryptCmd.Flags().StringVar(&encryptComment, "comment", "", "note stored in the encrypted header (see info)")
+	encryptCmd.Flags().BoolVar(&storeHostname, "hostname", false, "store the host name in the encrypted header")
+	addWorkersFlag(encryptCmd, "number of chunks encrypted concurrently")
+}
+
+// details stored in the encrypted header
+func getMetadata() *safelock.Metadata {
+	metadata := &safelock.Metadata{
+		CreatedAt:   time.Now().UTC(),
+		ToolVersion: fmt.Sprintf("%s %s", rootCmd.Name(), rootCmd.Version),
+		Comment:     encryptComment,
+	}
+
+	if storeHostname {
+		hostname, err := os.Hostname()
+
+		if err != nil {
+			utils.PrintErrsAndExit(fmt.Sprintf("failed to get host name > %s", err))
+		}
+
+		metadata.Hostname = hostname
+	}
+
+	return metadata
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mrf345/safelock-cli/passwords"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var genpassWords, genpassLength int
+var genpassRandom bool
+var genpassSeparator, genpassCharset, genpassChars string
+
+var genpassCharsets = map[string]string{
+	"lower":   passwords.CharsetLower,
+	"digits":  passwords.CharsetDigits,
+	"hex":     passwords.CharsetHex,
+	"alnum":   passwords.CharsetAlphanumeric,
+	"symbols": passwords.CharsetSymbols,
+}
+
+var genpassCmd = &cobra.Command{
+	Use:   "genpass",
+	Short: "genpass [--words count | --random --length count]",
+	Long:  "generate a diceware passphrase, or a random password with --random, that can be piped into encrypt",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var password string
+
+		if genpassRandom {
+			charset, ok := genpassCharsets[genpassCharset]
+
+			if genpassChars != "" {
+				charset, ok = genpassChars, true
+			}
+
+			if !ok {
+				utils.PrintErrsAndExit(
+					fmt.Sprintf("invalid charset (%s)", genpassCharset),
+					"expected: lower, digits, hex, alnum or symbols",
+				)
+			}
+
+			password, err = passwords.GenerateRandom(genpassLength, charset)
+		} else {
+			password, err = passwords.GeneratePassphrase(genpassWords, genpassSeparator)
+		}
+
+		if err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		// piped into another command such as encrypt, so it's shown once to be kept
+		if !term.IsTerminal(int(os.Stdout.Fd())) {
+			fmt.Fprintf(os.Stderr, "Generated password: %s\n", password)
+		}
+
+		fmt.Println(password)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(genpassCmd)
+	genpassCmd.Flags().IntVar(&genpassWords, "words", 6, "number of passphrase words")
+	genpassCmd.Flags().StringVar(&genpassSeparator, "separator", "-", "text passphrase words are joined with")
+	genpassCmd.Flags().BoolVar(&genpassRandom, "random", false, "generate a random password instead of a passphrase")
+	genpassCmd.Flags().IntVar(&genpassLength, "length", 24, "number of random password characters")
+	genpassCmd.Flags().StringVar(
+		&genpassCharset, "charset", "symbols", "random password characters (lower, digits, hex, alnum or symbols)",
+	)
+	genpassCmd.Flags().StringVar(&genpassChars, "chars", "", "custom random password characters, instead of --charset")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var infoAsJson, infoUnlock bool
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "info [encrypted file path]",
+	Long: "info [encrypted file path] shows how it was encrypted without a password, " +
+		"and its content summary and metadata with --unlock",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var inspection safelock.Inspection
+		const example = "example: safelock-cli info encrypted.bin"
+
+		if len(args) != 1 {
+			utils.PrintErrsAndExit("expected encrypted file path", example)
+		}
+
+		sl := safelock.New()
+		inputFile := openSlotsFile(args[0], os.O_RDONLY)
+		defer inputFile.Close()
+
+		// stdout is used for the information, so no logs
+		sl.Quiet = true
+
+		if infoUnlock || len(identityPaths) > 0 || keyfilePath != "" || passwordSource.IsSet() {
+			pwd := getInputPassword(args[0], sl)
+			inspection, err = sl.InspectWithPassword(context.TODO(), inputFile, pwd)
+		} else {
+			inspection, err = sl.Inspect(inputFile)
+		}
+
+		if err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		if infoAsJson {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			if err = encoder.Encode(inspection); err != nil {
+				utils.PrintErrsAndExit(err.Error())
+			}
+
+			return
+		}
+
+		printInspection(os.Stdout, inspection)
+	},
+}
+
+func printInspection(w io.Writer, inspection safelock.Inspection) {
+	var slots []string
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, slot := range inspection.KeySlots {
+		slots = append(slots, fmt.Sprintf("%d:%s", slot.Index, slot.Type))
+	}
+
+	fmt.Fprintf(table, "Format version\t%d\n", inspection.Version)
+	fmt.Fprintf(table, "Cipher\t%s\n", inspection.Cipher)
+	fmt.Fprintf(
+		table,
+		"KDF\t%s (iterations %d, memory %d KiB, threads %d, key length %d, salt length %d)\n",
+		inspection.KDF,
+		inspection.IterationCount,
+		inspection.MemSize,
+		inspection.Threads,
+		inspection.KeyLength,
+		inspection.SaltLength,
+	)
+	fmt.Fprintf(table, "Compression\t%s\n", inspection.Compression)
+	fmt.Fprintf(table, "Archival\t%s\n", inspection.Archival)
+	fmt.Fprintf(table, "Key slots\t%s\n", strings.Join(slots, ", "))
+	fmt.Fprintf(table, "Chunks\t%d\n", inspection.Chunks)
+	fmt.Fprintf(table, "Payload size\t%d\n", inspection.PayloadSize)
+	fmt.Fprintf(table, "Header size\t%d\n", inspection.HeaderSize)
+
+	if content := inspection.Content; content != nil {
+		createdAt := ""
+
+		if !content.Metadata.CreatedAt.IsZero() {
+			createdAt = content.Metadata.CreatedAt.Local().Format(time.DateTime)
+		}
+
+		fmt.Fprintf(table, "Entries\t%d\n", content.Entries)
+		fmt.Fprintf(table, "Uncompressed size\t%d\n", content.Size)
+		fmt.Fprintf(table, "Created\t%s\n", createdAt)
+		fmt.Fprintf(table, "Tool version\t%s\n", content.Metadata.ToolVersion)
+		fmt.Fprintf(table, "Hostname\t%s\n", content.Metadata.Hostname)
+		fmt.Fprintf(table, "Comment\t%s\n", content.Metadata.Comment)
+	}
+
+	table.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+	infoCmd.Flags().BoolVar(&infoAsJson, "json", false, "output the information in JSON format")
+	infoCmd.Flags().BoolVar(
+		&infoUnlock, "unlock", false, "ask for the password to also show the content summary and metadata",
+	)
+	infoCmd.Flags().StringArrayVarP(
+		&identityPaths, "identity", "i", nil, "identity file to unlock with instead of a password",
+	)
+	infoCmd.Flags().StringVar(
+		&keyfilePath, "keyfile", "", "keyfile to unlock with along with the password, or instead of it",
+	)
+}
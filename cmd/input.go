@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/mrf345/safelock-cli/passwords"
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/slErrs"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// identity files paths to decrypt with instead of a password
+var identityPaths []string
+
+// where to read the password from, instead of the environment variable, stdin or terminal
+var passwordSource passwords.Source
+
+// rules new passwords must pass (none, basic or strong)
+var passwordPolicy string
+
+// keyfile path to use along with the password, or instead of it
+var keyfilePath string
+
+// keyfile path to use along with new passwords of key slots, or instead of them
+var newKeyfilePath string
+
+// leave the keyfile out of new key slots, which keep it otherwise
+var dropKeyfile bool
+
+// number of chunks encrypted or decrypted concurrently
+var workers int
+
+// opens the encrypted input file or stdin, and gets the password from wherever is left free
+// unless identities are used instead
+func openEncryptedInput(inputPath string, sl *safelock.Safelock) (inputFile *os.File, pwd string) {
+	var err error
+
+	pwd = getInputPassword(inputPath, sl)
+
+	if inputPath == stdPath {
+		return os.Stdin, pwd
+	}
+
+	if inputFile, err = os.Open(inputPath); err != nil {
+		utils.PrintErrsAndExit((&slErrs.ErrInvalidInputPath{
+			Path: inputPath,
+			Err:  err,
+		}).Error())
+	}
+
+	return
+}
+
+// gets the password of the encrypted input, unless identities are used instead
+func getInputPassword(inputPath string, sl *safelock.Safelock) (pwd string) {
+	minLength := loadKeyfile(sl)
+
+	if len(identityPaths) > 0 {
+		sl.Identities = readIdentityFiles(identityPaths)
+		return
+	}
+
+	// stdin is used for the encrypted data, so the password has to come from elsewhere
+	return readPassword(minLength, inputPath == stdPath, false)
+}
+
+// reads the password from the set password source, otherwise from the environment variable,
+// then the terminal if `stdinUsed` or stdin, asking to enter it again if `confirm` and prompted
+func readPassword(minLength int, stdinUsed, confirm bool) (pwd string) {
+	var err error
+
+	switch {
+	case passwordSource.IsSet():
+		pwd, err = utils.GetSourcePassword(passwordSource, minLength)
+	case stdinUsed:
+		pwd, err = utils.GetTerminalPassword(minLength, confirm)
+	default:
+		pwd, err = utils.GetPassword(minLength, confirm)
+	}
+
+	if err != nil {
+		utils.PrintErrsAndExit(err.Error())
+	}
+
+	return
+}
+
+// loads the keyfile if set, and returns the minimum length of the password to read,
+// which can be left empty if a keyfile is used
+func loadKeyfile(sl *safelock.Safelock) (minPasswordLength int) {
+	var err error
+	var file *os.File
+
+	if keyfilePath == "" {
+		return sl.MinPasswordLength
+	}
+
+	if file, err = os.Open(keyfilePath); err != nil {
+		utils.PrintErrsAndExit((&slErrs.ErrInvalidInputPath{Path: keyfilePath, Err: err}).Error())
+	}
+	defer file.Close()
+
+	if err = sl.SetKeyfile(file); err != nil {
+		utils.PrintErrsAndExit(err.Error())
+	}
+
+	return 0
+}
+
+// password can be left empty if a keyfile is used
+func getPasswordLength(sl *safelock.Safelock) int {
+	if keyfilePath != "" {
+		return 0
+	}
+
+	return sl.MinPasswordLength
+}
+
+// loads the new keyfile of added key slots if set, and returns the minimum length of the new password
+// to read, which can be left empty if the slots have a keyfile, the new one or the kept one
+func loadNewKeyfile(sl *safelock.Safelock) (minPasswordLength int) {
+	var err error
+	var file *os.File
+
+	if dropKeyfile && newKeyfilePath != "" {
+		utils.PrintErrsAndExit("--drop-keyfile and --new-keyfile can't be used together")
+	}
+
+	sl.DropKeyfile = dropKeyfile
+
+	if newKeyfilePath == "" && dropKeyfile {
+		return sl.MinPasswordLength
+	} else if newKeyfilePath == "" {
+		return getPasswordLength(sl)
+	}
+
+	if file, err = os.Open(newKeyfilePath); err != nil {
+		utils.PrintErrsAndExit((&slErrs.ErrInvalidInputPath{Path: newKeyfilePath, Err: err}).Error())
+	}
+	defer file.Close()
+
+	if err = sl.SetNewKeyfile(file); err != nil {
+		utils.PrintErrsAndExit(err.Error())
+	}
+
+	return 0
+}
+
+func setPasswordPolicy(sl *safelock.Safelock) {
+	switch passwordPolicy {
+	case "none":
+		sl.PasswordPolicy = nil
+	case "basic":
+		sl.PasswordPolicy = safelock.BasicPasswordPolicy
+	case "strong":
+		sl.PasswordPolicy = safelock.StrongPasswordPolicy
+	default:
+		utils.PrintErrsAndExit(
+			fmt.Sprintf("invalid password policy (%s)", passwordPolicy),
+			"expected: none, basic or strong",
+		)
+	}
+}
+
+func addPasswordPolicyFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&passwordPolicy, "password-policy", "none", "rules new passwords must pass (none, basic or strong)",
+	)
+}
+
+func setWorkers(sl *safelock.Safelock) {
+	if workers < 1 {
+		utils.PrintErrsAndExit(fmt.Sprintf("invalid number of workers (%d)", workers))
+	}
+
+	sl.Workers = workers
+}
+
+func addWorkersFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().IntVar(&workers, "workers", runtime.NumCPU(), usage)
+}
+
+func readIdentityFiles(paths []string) (identities []*safelock.Identity) {
+	for _, path := range paths {
+		var file *os.File
+		var fileIdentities []*safelock.Identity
+		var err error
+
+		if file, err = os.Open(path); err != nil {
+			utils.PrintErrsAndExit((&slErrs.ErrInvalidInputPath{Path: path, Err: err}).Error())
+		}
+
+		fileIdentities, err = safelock.ReadIdentities(file)
+		file.Close()
+
+		if err != nil {
+			utils.PrintErrsAndExit(fmt.Sprintf("invalid identity file (%s) > %s", path, err))
+		}
+
+		identities = append(identities, fileIdentities...)
+	}
+
+	return
+}
+
+func parseRecipients(encoded []string) (recipients []*safelock.Recipient) {
+	for _, key := range encoded {
+		recipient, err := safelock.ParseRecipient(key)
+
+		if err != nil {
+			utils.PrintErrsAndExit(fmt.Sprintf("invalid recipient (%s) > %s", key, err))
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/slErrs"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var keyfileCmd = &cobra.Command{
+	Use:   "keyfile",
+	Short: "keyfile [generate]",
+	Long:  "manage keyfiles used along with passwords (--keyfile), or instead of them",
+}
+
+var keyfileGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "generate [keyfile path]",
+	Long:  "generate [keyfile path] with random high-entropy content",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var outputFile *os.File
+		const example = "example: safelock-cli keyfile generate secret.key"
+
+		if len(args) != 1 {
+			utils.PrintErrsAndExit("expected keyfile path", example)
+		}
+
+		// never overwrite existing keyfiles, files encrypted with them would be lost
+		fileFlags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+
+		if outputFile, err = os.OpenFile(args[0], fileFlags, 0600); err != nil {
+			utils.PrintErrsAndExit((&slErrs.ErrInvalidOutputPath{
+				Path: args[0],
+				Err:  err,
+			}).Error())
+		}
+		defer outputFile.Close()
+
+		if err = safelock.GenerateKeyfile(outputFile); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keyfileCmd)
+	keyfileCmd.AddCommand(keyfileGenerateCmd)
+}
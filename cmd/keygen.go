@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/slErrs"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "keygen [identity file path]",
+	Long:  "keygen [identity file path, or none for stdout] generates a key pair to encrypt files to",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var identity *safelock.Identity
+		var outputFile = os.Stdout
+		const example = "example: safelock-cli keygen identity.txt"
+
+		if len(args) > 1 {
+			utils.PrintErrsAndExit("too many arguments", example)
+		}
+
+		if identity, err = safelock.GenerateIdentity(); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		// never overwrite existing identities, files encrypted to them would be lost
+		if len(args) == 1 {
+			fileFlags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+
+			if outputFile, err = os.OpenFile(args[0], fileFlags, 0600); err != nil {
+				utils.PrintErrsAndExit((&slErrs.ErrInvalidOutputPath{
+					Path: args[0],
+					Err:  err,
+				}).Error())
+			}
+			defer outputFile.Close()
+		}
+
+		recipient := identity.Recipient().String()
+		_, err = fmt.Fprintf(
+			outputFile,
+			"# created: %s\n# public key: %s\n%s\n",
+			time.Now().Format(time.RFC3339),
+			recipient,
+			identity,
+		)
+
+		if err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		fmt.Fprintf(os.Stderr, "Public key: %s\n", recipient)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keygenCmd)
+}
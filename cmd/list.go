@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var listAsJson bool
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list [encrypted file path]",
+	Long:  "list [encrypted file path or - for stdin] files without extracting them",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var pwd string
+		var sl *safelock.Safelock
+		var inputFile *os.File
+		var entries []safelock.ArchiveEntry
+		const example = "example: safelock-cli list encrypted.bin"
+
+		switch len(args) {
+		case 0:
+			utils.PrintErrsAndExit("missing input file path", example)
+		case 1:
+			break
+		default:
+			utils.PrintErrsAndExit("too many arguments", example)
+		}
+
+		sl = safelock.New()
+		inputFile, pwd = openEncryptedInput(args[0], sl)
+		defer inputFile.Close()
+
+		// stdout is used for the list, so no logs
+		sl.Quiet = true
+
+		if entries, err = sl.List(context.TODO(), inputFile, pwd); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		if listAsJson {
+			printEntriesJson(entries)
+		} else {
+			printEntriesTable(entries)
+		}
+	},
+}
+
+func printEntriesTable(entries []safelock.ArchiveEntry) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(table, "MODE\tSIZE\tMODIFIED\tNAME")
+
+	for _, entry := range entries {
+		fmt.Fprintf(
+			table,
+			"%s\t%d\t%s\t%s\n",
+			entry.Mode,
+			entry.Size,
+			entry.ModTime.Format(time.DateTime),
+			entry.Name,
+		)
+	}
+
+	table.Flush()
+}
+
+// archive entry with human readable mode
+type jsonEntry struct {
+	safelock.ArchiveEntry
+	Mode string `json:"mode"`
+}
+
+func printEntriesJson(entries []safelock.ArchiveEntry) {
+	encoder := json.NewEncoder(os.Stdout)
+	jsonEntries := make([]jsonEntry, 0, len(entries))
+	encoder.SetIndent("", "  ")
+
+	for _, entry := range entries {
+		jsonEntries = append(jsonEntries, jsonEntry{ArchiveEntry: entry, Mode: entry.Mode.String()})
+	}
+
+	if err := encoder.Encode(jsonEntries); err != nil {
+		utils.PrintErrsAndExit(err.Error())
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().BoolVar(&listAsJson, "json", false, "output the list in JSON format")
+	listCmd.Flags().StringArrayVarP(
+		&identityPaths, "identity", "i", nil, "identity file to decrypt with instead of a password",
+	)
+	listCmd.Flags().StringVar(
+		&keyfilePath, "keyfile", "", "keyfile to decrypt with along with the password, or instead of it",
+	)
+}
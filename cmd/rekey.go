@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "rekey [encrypted file path]",
+	Long:  "rekey [encrypted file path] changes its password in place, without re-encrypting it",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var pwd, newPwd string
+		const example = "example: safelock-cli rekey encrypted.bin"
+
+		if len(args) != 1 {
+			utils.PrintErrsAndExit("expected encrypted file path", example)
+		}
+
+		sl := safelock.New()
+		file := openSlotsFile(args[0], os.O_RDWR)
+		defer file.Close()
+		setPasswordPolicy(sl)
+		pwd = readPassword(loadKeyfile(sl), false, false)
+
+		if newPwd, err = utils.GetNewPassword(loadNewKeyfile(sl)); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		if err = sl.Rekey(context.TODO(), file, pwd, newPwd); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rekeyCmd)
+	rekeyCmd.Flags().StringVar(
+		&keyfilePath, "keyfile", "", "keyfile to unlock with along with the password, or instead of it",
+	)
+	rekeyCmd.Flags().StringVar(
+		&newKeyfilePath, "new-keyfile", "", "keyfile used along with the new password, or instead of it",
+	)
+	rekeyCmd.Flags().BoolVar(
+		&dropKeyfile, "drop-keyfile", false, "leave the keyfile out of the new password slot, which keeps it otherwise",
+	)
+	addPasswordPolicyFlag(rekeyCmd)
+}
@@ -10,6 +10,9 @@ import (
 
 var beQuiet bool
 
+// path used to refer to stdin or stdout
+const stdPath = "-"
+
 var rootCmd = &cobra.Command{
 	Use:     "safelock-cli",
 	Short:   "Simple tool to encrypt/decrypt files with AES encryption",
@@ -29,4 +32,16 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&beQuiet, "quiet", false, "disable output logs")
+	rootCmd.PersistentFlags().StringVar(
+		&passwordSource.Env, "password-env", "", "read the password from the named environment variable",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&passwordSource.File, "password-file", "", "read the password from the first line of a file",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&passwordSource.Fd, "password-fd", 0, "read the password from an open file descriptor",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&passwordSource.Cmd, "password-cmd", "", "read the password from the output of a shell command",
+	)
 }
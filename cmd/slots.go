@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/slErrs"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var slotRecipient string
+
+var slotsCmd = &cobra.Command{
+	Use:   "slots",
+	Short: "slots [add|remove|list]",
+	Long:  "manage the key slots that can decrypt an encrypted file, without re-encrypting it",
+}
+
+var slotsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list [encrypted file path]",
+	Long:  "list [encrypted file path] used key slots",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var slots []safelock.KeySlotInfo
+		const example = "example: safelock-cli slots list encrypted.bin"
+
+		if len(args) != 1 {
+			utils.PrintErrsAndExit("expected encrypted file path", example)
+		}
+
+		inputFile := openSlotsFile(args[0], os.O_RDONLY)
+		defer inputFile.Close()
+
+		if slots, err = safelock.New().ListKeySlots(inputFile); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "INDEX\tTYPE")
+
+		for _, slot := range slots {
+			fmt.Fprintf(table, "%d\t%s\n", slot.Index, slot.Type)
+		}
+
+		table.Flush()
+	},
+}
+
+var slotsAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add [encrypted file path]",
+	Long:  "add [encrypted file path] key slot for a new password, or a recipient with --recipient",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		const example = "example: safelock-cli slots add encrypted.bin"
+
+		if len(args) != 1 {
+			utils.PrintErrsAndExit("expected encrypted file path", example)
+		}
+
+		sl := safelock.New()
+		file := openSlotsFile(args[0], os.O_RDWR)
+		defer file.Close()
+		setPasswordPolicy(sl)
+		pwd := getInputPassword(args[0], sl)
+
+		if slotRecipient != "" {
+			recipient := parseRecipients([]string{slotRecipient})[0]
+			err = sl.AddRecipientSlot(context.TODO(), file, pwd, recipient)
+		} else {
+			var newPwd string
+
+			if newPwd, err = utils.GetNewPassword(loadNewKeyfile(sl)); err != nil {
+				utils.PrintErrsAndExit(err.Error())
+			}
+
+			err = sl.AddPasswordSlot(context.TODO(), file, pwd, newPwd)
+		}
+
+		if err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+	},
+}
+
+var slotsRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "remove [encrypted file path] [slot index]",
+	Long:  "remove [encrypted file path] [slot index] key slot, so it can no longer decrypt the file",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var idx int
+		const example = "example: safelock-cli slots remove encrypted.bin 1"
+
+		if len(args) != 2 {
+			utils.PrintErrsAndExit("expected encrypted file path and slot index", example)
+		}
+
+		if idx, err = strconv.Atoi(args[1]); err != nil {
+			utils.PrintErrsAndExit(fmt.Sprintf("invalid slot index (%s)", args[1]), example)
+		}
+
+		sl := safelock.New()
+		file := openSlotsFile(args[0], os.O_RDWR)
+		defer file.Close()
+		pwd := getInputPassword(args[0], sl)
+
+		if err = sl.RemoveKeySlot(context.TODO(), file, pwd, idx); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+	},
+}
+
+// opens an encrypted file whose slots are changed in place, so it can't be stdin
+func openSlotsFile(path string, flag int) (file *os.File) {
+	var err error
+
+	if file, err = os.OpenFile(path, flag, 0); err != nil {
+		utils.PrintErrsAndExit((&slErrs.ErrInvalidInputPath{
+			Path: path,
+			Err:  err,
+		}).Error())
+	}
+
+	return
+}
+
+func init() {
+	rootCmd.AddCommand(slotsCmd)
+	slotsCmd.AddCommand(slotsListCmd, slotsAddCmd, slotsRemoveCmd)
+
+	for _, cmd := range []*cobra.Command{slotsAddCmd, slotsRemoveCmd} {
+		cmd.Flags().StringArrayVarP(
+			&identityPaths, "identity", "i", nil, "identity file to unlock with instead of a password",
+		)
+		cmd.Flags().StringVar(
+			&keyfilePath, "keyfile", "", "keyfile to unlock with along with the password, or instead of it",
+		)
+	}
+
+	slotsAddCmd.Flags().StringVar(
+		&newKeyfilePath, "new-keyfile", "", "keyfile of the new slot along with the new password, or instead of it",
+	)
+	slotsAddCmd.Flags().BoolVar(
+		&dropKeyfile, "drop-keyfile", false, "leave the keyfile out of the new slot, which keeps it otherwise",
+	)
+	slotsAddCmd.Flags().StringVarP(
+		&slotRecipient, "recipient", "r", "", "public key to add instead of a new password (see keygen)",
+	)
+	addPasswordPolicyFlag(slotsAddCmd)
+}
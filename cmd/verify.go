@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	"github.com/mrf345/safelock-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var verifyAsJson bool
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "verify [encrypted file path]",
+	Long:  "verify [encrypted file path or - for stdin] decrypts and authenticates without writing any files",
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var pwd string
+		var sl *safelock.Safelock
+		var inputFile *os.File
+		var report safelock.VerifyReport
+		const example = "example: safelock-cli verify encrypted.bin"
+
+		switch len(args) {
+		case 0:
+			utils.PrintErrsAndExit("missing input file path", example)
+		case 1:
+			break
+		default:
+			utils.PrintErrsAndExit("too many arguments", example)
+		}
+
+		sl = safelock.New()
+		inputFile, pwd = openEncryptedInput(args[0], sl)
+		defer inputFile.Close()
+
+		// stdout is used for the report, so no logs
+		sl.Quiet = true
+
+		if report, err = sl.Verify(context.TODO(), inputFile, pwd); err != nil {
+			utils.PrintErrsAndExit(err.Error())
+		}
+
+		if verifyAsJson {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+
+			if err = encoder.Encode(report); err != nil {
+				utils.PrintErrsAndExit(err.Error())
+			}
+
+			return
+		}
+
+		fmt.Printf(
+			"Verified %d entries of %d bytes in %d authenticated chunks\n",
+			report.Entries,
+			report.Size,
+			report.Chunks,
+		)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&verifyAsJson, "json", false, "output the report in JSON format")
+	verifyCmd.Flags().StringArrayVarP(
+		&identityPaths, "identity", "i", nil, "identity file to decrypt with instead of a password",
+	)
+	verifyCmd.Flags().StringVar(
+		&keyfilePath, "keyfile", "", "keyfile to decrypt with along with the password, or instead of it",
+	)
+}
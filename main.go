@@ -22,10 +22,18 @@
 //
 //	safelock-cli decrypt encrypted_file_path decrypted_files_path
 //
+// Or to decrypt a stream from stdin
+//
+//	cat encrypted_file_path | safelock-cli decrypt - decrypted_files_path
+//
 // If you want it to run silently with no interaction
 //
 //	echo "password123456" | safelock-cli encrypt path_to_encrypt encrypted_file_path --quiet
 //
+// Or to encrypt from stdin into stdout
+//
+//	cat file.txt | SAFELOCK_PASSWORD="password123456" safelock-cli encrypt - - --name file.txt > encrypted_file_path
+//
 // [safelock-cli/safelock]: https://pkg.go.dev/github.com/mrf345/safelock-cli/safelock
 // [repo]: https://github.com/mrf345/safelock-cli
 package main
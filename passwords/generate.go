@@ -0,0 +1,93 @@
+package passwords
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// characters sets random passwords can be generated from
+const (
+	CharsetLower        = "abcdefghijklmnopqrstuvwxyz"
+	CharsetDigits       = "0123456789"
+	CharsetHex          = CharsetDigits + "abcdef"
+	CharsetAlphanumeric = CharsetLower + "ABCDEFGHIJKLMNOPQRSTUVWXYZ" + CharsetDigits
+	CharsetSymbols      = CharsetAlphanumeric + "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+)
+
+// EFF's large wordlist (https://www.eff.org/dice) licensed under CC BY 3.0 US,
+// each line is the dice rolls of a word followed by it
+//
+//go:embed wordlist.txt
+var wordlistFile string
+
+var wordlist = func() (words []string) {
+	for _, line := range strings.Split(strings.TrimSpace(wordlistFile), "\n") {
+		if _, word, ok := strings.Cut(line, "\t"); ok {
+			words = append(words, word)
+		}
+	}
+
+	return
+}()
+
+// generates a diceware passphrase of `count` random words of the embedded wordlist joined by `separator`,
+// each word adds about 12.9 bits of entropy
+func GeneratePassphrase(count int, separator string) (passphrase string, err error) {
+	words := make([]string, count)
+
+	if count < 1 {
+		return "", fmt.Errorf("invalid passphrase words count (%d)", count)
+	}
+
+	for idx := range words {
+		var pick int
+
+		if pick, err = randomIndex(len(wordlist)); err != nil {
+			return
+		}
+
+		words[idx] = wordlist[pick]
+	}
+
+	return strings.Join(words, separator), nil
+}
+
+// generates a random password of `length` characters picked from `charset`, such as [passwords.CharsetSymbols]
+func GenerateRandom(length int, charset string) (password string, err error) {
+	chars := []rune(charset)
+	picked := make([]rune, length)
+
+	if length < 1 {
+		return "", fmt.Errorf("invalid password length (%d)", length)
+	}
+
+	if len(chars) < 2 {
+		return "", fmt.Errorf("invalid charset (%s) expected at least 2 characters", charset)
+	}
+
+	for idx := range picked {
+		var pick int
+
+		if pick, err = randomIndex(len(chars)); err != nil {
+			return
+		}
+
+		picked[idx] = chars[pick]
+	}
+
+	return string(picked), nil
+}
+
+// uniformly picks a random index lower than `size`
+func randomIndex(size int) (int, error) {
+	idx, err := rand.Int(rand.Reader, big.NewInt(int64(size)))
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number > %w", err)
+	}
+
+	return int(idx.Int64()), nil
+}
@@ -0,0 +1,37 @@
+package passwords_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/passwords"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePassphrase(t *testing.T) {
+	assert := assert.New(t)
+
+	first, firstErr := passwords.GeneratePassphrase(6, " ")
+	second, secondErr := passwords.GeneratePassphrase(6, " ")
+	_, invalidErr := passwords.GeneratePassphrase(0, "-")
+
+	assert.Nil(firstErr)
+	assert.Nil(secondErr)
+	assert.NotNil(invalidErr)
+	assert.Len(strings.Split(first, " "), 6)
+	assert.NotEqual(first, second)
+}
+
+func TestGenerateRandom(t *testing.T) {
+	assert := assert.New(t)
+
+	password, err := passwords.GenerateRandom(32, passwords.CharsetHex)
+	_, invalidLengthErr := passwords.GenerateRandom(0, passwords.CharsetHex)
+	_, invalidCharsetErr := passwords.GenerateRandom(32, "a")
+
+	assert.Nil(err)
+	assert.Len(password, 32)
+	assert.Empty(strings.Trim(password, passwords.CharsetHex))
+	assert.NotNil(invalidLengthErr)
+	assert.NotNil(invalidCharsetErr)
+}
@@ -0,0 +1,3 @@
+// resolves passwords from the sources supported by safelock-cli (environment variables, files,
+// file descriptors and commands), to be reused by tools embedding safelock
+package passwords
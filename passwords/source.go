@@ -0,0 +1,116 @@
+package passwords
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// no password source is set
+var ErrNoSource = errors.New("no password source is set")
+
+// where a password is read from, only the first set source is used in the order of the fields
+type Source struct {
+	// name of an environment variable holding the password
+	Env string
+	// path of a file whose first line is the password
+	File string
+	// open file descriptor to read the password line from, stdin (0) is left out since it's the default
+	Fd int
+	// shell command that prints the password, such as `pass show backups`
+	Cmd string
+}
+
+// checks if any of the sources is set
+func (s Source) IsSet() bool {
+	return s.Env != "" || s.File != "" || s.Fd > 0 || s.Cmd != ""
+}
+
+// reads the password from the first set source, or returns [passwords.ErrNoSource]
+func (s Source) Read() (password string, err error) {
+	switch {
+	case s.Env != "":
+		return readEnv(s.Env)
+	case s.File != "":
+		return readFile(s.File)
+	case s.Fd > 0:
+		return readFd(s.Fd)
+	case s.Cmd != "":
+		return readCmd(s.Cmd)
+	}
+
+	return "", ErrNoSource
+}
+
+func readEnv(name string) (password string, err error) {
+	password, ok := os.LookupEnv(name)
+
+	if !ok {
+		err = fmt.Errorf("password environment variable (%s) is not set", name)
+	}
+
+	return
+}
+
+func readFile(path string) (password string, err error) {
+	var file *os.File
+
+	if file, err = os.Open(path); err != nil {
+		return "", fmt.Errorf("failed to open password file > %w", err)
+	}
+	defer file.Close()
+
+	return readLine(file)
+}
+
+func readFd(fd int) (password string, err error) {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+
+	if file == nil {
+		return "", fmt.Errorf("invalid password file descriptor (%d)", fd)
+	}
+	defer file.Close()
+
+	return readLine(file)
+}
+
+func readCmd(command string) (password string, err error) {
+	var output []byte
+	var cmd *exec.Cmd
+
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	// password managers might need to ask for their own passphrase
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	if output, err = cmd.Output(); err != nil {
+		return "", fmt.Errorf("password command failed > %w", err)
+	}
+
+	return readLine(strings.NewReader(string(output)))
+}
+
+// reads the first line of `r` without its line ending
+func readLine(r io.Reader) (line string, err error) {
+	if line, err = bufio.NewReader(r).ReadString('\n'); err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password > %w", err)
+	}
+
+	return TrimLineEnding(line), nil
+}
+
+// removes the line ending of `line`, keeping any other leading or trailing spaces
+// since they're part of the password
+func TrimLineEnding(line string) string {
+	return strings.TrimRight(line, "\r\n")
+}
@@ -0,0 +1,87 @@
+package passwords_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/passwords"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPasswordSources(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password.txt")
+	reader, writer, _ := os.Pipe()
+
+	_ = os.WriteFile(passwordFile, []byte("from file\nsecond line\n"), 0600)
+	_, _ = writer.WriteString("from fd\n")
+	writer.Close()
+	t.Setenv("TEST_SAFELOCK_PASSWORD", "from env")
+
+	cases := map[string]passwords.Source{
+		"from env":  {Env: "TEST_SAFELOCK_PASSWORD", File: passwordFile},
+		"from file": {File: passwordFile},
+		"from fd":   {Fd: int(reader.Fd())},
+	}
+
+	if runtime.GOOS != "windows" {
+		cases["from cmd"] = passwords.Source{Cmd: "echo 'from cmd'"}
+	}
+
+	for expected, source := range cases {
+		password, err := source.Read()
+
+		assert.Nil(err)
+		assert.True(source.IsSet())
+		assert.Equal(expected, password)
+	}
+}
+
+func TestReadPasswordMissingSources(t *testing.T) {
+	assert := assert.New(t)
+
+	_, noSourceErr := passwords.Source{}.Read()
+	_, envErr := passwords.Source{Env: "TEST_SAFELOCK_MISSING_PASSWORD"}.Read()
+	_, fileErr := passwords.Source{File: filepath.Join(t.TempDir(), "missing.txt")}.Read()
+	_, cmdErr := passwords.Source{Cmd: "exit 1"}.Read()
+
+	assert.ErrorIs(noSourceErr, passwords.ErrNoSource)
+	assert.NotNil(envErr)
+	assert.NotNil(fileErr)
+	assert.NotNil(cmdErr)
+}
+
+func TestReadPasswordKeepsSpaces(t *testing.T) {
+	assert := assert.New(t)
+	expected := "  spaced password \t"
+	passwordFile := filepath.Join(t.TempDir(), "password.txt")
+	reader, writer, _ := os.Pipe()
+
+	_ = os.WriteFile(passwordFile, []byte(expected+"\r\n"), 0600)
+	_, _ = writer.WriteString(expected + "\n")
+	writer.Close()
+	t.Setenv("TEST_SAFELOCK_PASSWORD", expected)
+
+	sources := []passwords.Source{
+		{Env: "TEST_SAFELOCK_PASSWORD"},
+		{File: passwordFile},
+		{Fd: int(reader.Fd())},
+	}
+
+	if runtime.GOOS != "windows" {
+		sources = append(sources, passwords.Source{Cmd: "printf '  spaced password \\t\\n'"})
+	}
+
+	for _, source := range sources {
+		password, err := source.Read()
+
+		assert.Nil(err)
+		assert.Equal(expected, password)
+	}
+
+	assert.Equal(expected, passwords.TrimLineEnding(expected+"\r\n"))
+	assert.Equal(expected, passwords.TrimLineEnding(expected))
+}
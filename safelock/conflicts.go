@@ -0,0 +1,77 @@
+package safelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mholt/archiver/v4"
+	"github.com/mrf345/safelock-cli/slErrs"
+)
+
+// how decrypted files that already exist in the output path are handled
+type ConflictPolicy string
+
+const (
+	ConflictOverwrite ConflictPolicy = "overwrite" // replace existing files
+	ConflictSkip      ConflictPolicy = "skip"      // keep existing files
+	ConflictRename    ConflictPolicy = "rename"    // keep existing files and add a suffix to decrypted ones
+	ConflictFail      ConflictPolicy = "fail"      // stop decrypting with [slErrs.ErrFileConflict]
+	ConflictNewer     ConflictPolicy = "newer"     // replace existing files only if decrypted ones are newer
+)
+
+// checks if `fullPath` already exists, and returns where `file` should be written to instead if so,
+// or an empty path if it shouldn't be written at all
+func (fe *fileExtractor) resolveConflict(fullPath string, file archiver.File) (outputPath string, err error) {
+	var action string
+	existing, statErr := os.Lstat(fullPath)
+
+	if statErr != nil {
+		return fullPath, nil
+	}
+
+	switch fe.OnConflict {
+	case ConflictSkip:
+		action = "skipped"
+	case ConflictFail:
+		action = "failed"
+		err = &slErrs.ErrFileConflict{Path: file.NameInArchive}
+	case ConflictRename:
+		outputPath = getFreePath(fullPath)
+		action = fmt.Sprintf("renamed to %s", filepath.Base(outputPath))
+	case ConflictNewer:
+		if file.ModTime().After(existing.ModTime()) {
+			outputPath = fullPath
+			action = "overwritten"
+		} else {
+			action = "skipped"
+		}
+	default:
+		outputPath = fullPath
+		action = "overwritten"
+	}
+
+	fe.statusObs.next(StatusItem{
+		Event: StatusConflict,
+		Msg:   fmt.Sprintf("Existing file %s", action),
+		Path:  fullPath,
+		Err:   err,
+	})
+
+	return
+}
+
+// adds a numeric suffix to `fullPath` file name till it doesn't match any existing file
+func getFreePath(fullPath string) (freePath string) {
+	ext := filepath.Ext(fullPath)
+	base := strings.TrimSuffix(fullPath, ext)
+
+	for idx := 1; ; idx++ {
+		freePath = fmt.Sprintf("%s_%d%s", base, idx, ext)
+
+		if _, err := os.Lstat(freePath); err != nil {
+			return
+		}
+	}
+}
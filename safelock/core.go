@@ -2,6 +2,7 @@ package safelock
 
 import (
 	"crypto/cipher"
+	"encoding/binary"
 	"fmt"
 	"io"
 
@@ -10,38 +11,66 @@ import (
 	"golang.org/x/crypto/chacha20poly1305"
 )
 
+// derives the key that authenticates the header from the file key
+var headerKeyInfo = []byte("safelock-cli header")
+
 type aeadWrapper struct {
 	config    EncryptionConfig
+	preamble  []byte
+	slots     keySlots
 	salt      []byte
 	pwd       []byte
 	errs      chan error
-	counter   int
 	aead      cipher.AEAD
 	aeadReady bool
 	aeadDone  chan bool
+	// whether chunks are flagged as final or not, and the header is encrypted with `headerKey`,
+	// which legacy files do neither of
+	flagsFinal bool
+	headerKey  []byte
 }
 
-func newAeadWriter(pwd string, w io.Writer, config EncryptionConfig, errs chan error) *aeadWrapper {
+func newAeadWriter(pwd string, w io.Writer, config EncryptionConfig, pre preamble, errs chan error) *aeadWrapper {
 	aw := &aeadWrapper{
-		pwd:      []byte(pwd),
-		config:   config,
-		errs:     errs,
-		aeadDone: make(chan bool, 2),
+		pwd:        []byte(pwd),
+		config:     config,
+		preamble:   pre.bytes(),
+		errs:       errs,
+		aeadDone:   make(chan bool, 2),
+		flagsFinal: !pre.isLegacy(),
 	}
-	go aw.writeSaltAndLoad(w)
+	go aw.writeKeySlotsAndLoad(w)
 	return aw
 }
 
-func newAeadReader(pwd string, r InputReader, config EncryptionConfig, errs chan error) *aeadWrapper {
-	aw := &aeadWrapper{
-		pwd:      []byte(pwd),
-		config:   config,
-		errs:     errs,
-		aeadDone: make(chan bool, 2),
+// reads and unlocks the key slots of `r`, or its salt if it's of an older format
+func newAeadReader(
+	pwd string,
+	r io.Reader,
+	config EncryptionConfig,
+	pre preamble,
+	errs chan error,
+) (aw *aeadWrapper, err error) {
+	aw = &aeadWrapper{
+		pwd:        []byte(pwd),
+		config:     config,
+		preamble:   pre.bytes(),
+		errs:       errs,
+		aeadDone:   make(chan bool, 2),
+		flagsFinal: !pre.isLegacy(),
+	}
+
+	if !pre.isLegacy() {
+		err = aw.readKeySlotsAndLoad(r)
+		return
 	}
-	aw.readSalt(r)
+
+	if err = aw.readSalt(r); err != nil {
+		return
+	}
+
 	go aw.loadAead()
-	return aw
+	return
 }
 
 func (aw *aeadWrapper) getAead() cipher.AEAD {
@@ -52,35 +81,63 @@ func (aw *aeadWrapper) getAead() cipher.AEAD {
 	return aw.aead
 }
 
-func (aw *aeadWrapper) writeSaltAndLoad(w io.Writer) {
-	aw.salt = (<-aw.config.random)[:aw.config.SaltLength]
+// wraps a new random file key in the key slots, and writes them after the preamble
+func (aw *aeadWrapper) writeKeySlotsAndLoad(w io.Writer) {
+	var err error
+	fileKey := (<-aw.config.random)[:fileKeyLength]
+
+	if aw.slots, err = newKeySlots(string(aw.pwd), aw.config, fileKey); err != nil {
+		aw.errs <- fmt.Errorf("failed to create key slots > %w", err)
+		return
+	}
+
+	if _, err = w.Write(append(aw.preamble, aw.slots.bytes()...)); err != nil {
+		aw.errs <- fmt.Errorf("failed to write key slots > %w", err)
+		return
+	}
+
+	aw.loadFileKey(fileKey)
+}
+
+func (aw *aeadWrapper) readKeySlotsAndLoad(r io.Reader) (err error) {
+	var fileKey []byte
+
+	if aw.slots, err = readKeySlots(r); err != nil {
+		return
+	}
 
-	if _, err := w.Write(aw.salt); err != nil {
-		aw.errs <- fmt.Errorf("failed to write salt > %w", err)
+	if fileKey, _, err = aw.slots.unlock(string(aw.pwd), aw.config); err != nil {
 		return
 	}
 
-	aw.loadAead()
+	aw.loadFileKey(fileKey)
+	return
 }
 
-func (aw *aeadWrapper) readSalt(r InputReader) {
+func (aw *aeadWrapper) loadFileKey(fileKey []byte) {
 	var err error
-	var sought int
 
-	if _, err = r.Seek(0, io.SeekStart); err != nil {
-		aw.errs <- fmt.Errorf("failed to read input > %w", err)
+	if aw.aead, err = chacha20poly1305.NewX(fileKey); err != nil {
+		aw.errs <- fmt.Errorf("failed to create AEAD > %w", err)
 		return
 	}
 
+	aw.headerKey = getHeaderKey(fileKey)
+	aw.aeadDone <- true
+}
+
+func (aw *aeadWrapper) readSalt(r io.Reader) (err error) {
+	var sought int
+
 	aw.salt = make([]byte, aw.config.SaltLength)
 
-	if sought, err = r.Read(aw.salt); err != nil {
-		aw.errs <- fmt.Errorf("failed to read salt from input > %w", err)
-		return
+	if sought, err = io.ReadFull(r, aw.salt); err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("failed to read salt from input > %w", err)
 	} else if sought != aw.config.SaltLength {
-		aw.errs <- fmt.Errorf("invalid file or corrupted encryption (missing salt)")
-		return
+		return fmt.Errorf("invalid file or corrupted encryption (missing salt)")
 	}
+
+	return nil
 }
 
 func (aw *aeadWrapper) loadAead() {
@@ -103,33 +160,53 @@ func (aw *aeadWrapper) loadAead() {
 	aw.aeadDone <- true
 }
 
-func (aw *aeadWrapper) encrypt(chunk []byte) []byte {
-	idx := []byte(fmt.Sprintf("%d", aw.counter))
-	aead := aw.getAead()
-	nonce := (<-aw.config.random)[:aead.NonceSize()]
-	aw.counter += 1
-	return append(nonce, aead.Seal(nil, nonce, chunk, idx)...)
+// binds chunks to their position `idx`, and unless legacy to whether they're the final one
+func (aw *aeadWrapper) additionalData(idx int, final bool) []byte {
+	if !aw.flagsFinal {
+		return []byte(fmt.Sprintf("%d", idx))
+	}
+
+	data := binary.BigEndian.AppendUint64(nil, uint64(idx))
+
+	if final {
+		return append(data, 1)
+	}
+
+	return append(data, 0)
 }
 
-func (aw *aeadWrapper) decrypt(chunk []byte) (output []byte, err error) {
-	aead := aw.getAead()
+// encrypts the chunk at position `idx`, safe to call concurrently once the AEAD is loaded
+func (aw *aeadWrapper) encrypt(chunk []byte, idx int, final bool) []byte {
+	nonce := (<-aw.config.random)[:aw.aead.NonceSize()]
+	return aw.aead.Seal(nonce, nonce, chunk, aw.additionalData(idx, final))
+}
 
-	if aead.NonceSize() > len(chunk) {
-		err = &slErrs.ErrFailedToAuthenticate{Msg: "invalid chunk size"}
-		aw.errs <- err
+// decrypts the chunk at position `idx`, safe to call concurrently once the AEAD is loaded
+func (aw *aeadWrapper) decrypt(chunk []byte, idx int, final bool) (output []byte, err error) {
+	if aw.aead.NonceSize() > len(chunk) {
+		err = &slErrs.ErrFailedToAuthenticate{Msg: fmt.Sprintf("invalid chunk (%d) size", idx)}
 		return
 	}
 
-	idx := []byte(fmt.Sprintf("%d", aw.counter))
-	nonce := chunk[:aead.NonceSize()]
-	encrypted := chunk[aead.NonceSize():]
+	nonce := chunk[:aw.aead.NonceSize()]
+	encrypted := chunk[aw.aead.NonceSize():]
 
-	if output, err = aead.Open(nil, nonce, encrypted, idx); err != nil {
-		err = &slErrs.ErrFailedToAuthenticate{Msg: err.Error()}
-		aw.errs <- err
-		return
+	if output, err = aw.aead.Open(nil, nonce, encrypted, aw.additionalData(idx, final)); err != nil {
+		msg := fmt.Sprintf("chunk (%d) %s", idx, err)
+		err = &slErrs.ErrFailedToAuthenticate{Msg: msg}
+
+		// older formats derive the key from the password, which only the first chunk can tell is wrong
+		if idx == 0 && aw.salt != nil {
+			err = &slErrs.ErrNoMatchingKey{Msg: msg}
+		}
+
+		// a valid chunk that wasn't meant to be the final one, means the chunks after it were removed
+		if final {
+			if _, notFinalErr := aw.aead.Open(nil, nonce, encrypted, aw.additionalData(idx, false)); notFinalErr == nil {
+				err = &slErrs.ErrTruncatedInput{Chunk: idx + 1}
+			}
+		}
 	}
 
-	aw.counter += 1
 	return
 }
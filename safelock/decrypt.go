@@ -4,19 +4,48 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
 
 	"github.com/mholt/archiver/v4"
 	slErrs "github.com/mrf345/safelock-cli/slErrs"
 	"github.com/mrf345/safelock-cli/utils"
 )
 
-// decrypts `input` which must be an object that implements [io.Reader] and [io.Seeker] such as [os.File]
+// decrypts `input` which must be an object that implements [io.Reader] such as [os.File] or [os.Stdin]
 // and then outputs the content into `outputPath` which must be a valid path to an existing directory
 //
+// NOTE: files encrypted before the format was versioned also require `input` to implement [io.Seeker]
+//
 // NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
-func (sl *Safelock) Decrypt(ctx context.Context, input InputReader, outputPath, password string) (err error) {
+func (sl *Safelock) Decrypt(ctx context.Context, input io.Reader, outputPath, password string) (err error) {
+	filter := sl.getFilter()
+	extractor := newFileExtractor(outputPath, sl.DecryptionConfig, sl.StatusObs)
+
+	return sl.extract(ctx, input, password, extraction{
+		act:        "Decrypting",
+		done:       "All set and decrypted!",
+		validate:   func() error { return sl.validateDecryptionInputs(outputPath, filter) },
+		handleFile: getFilteredFileHandler(filter, extractor.handleFile),
+		finish:     extractor.finish,
+	})
+}
+
+// settings of how encrypted files content is extracted
+type extraction struct {
+	// status update action and completion texts
+	act, done string
+	// optional validation of the inputs before reading
+	validate func() error
+	// handles each file read from the archive
+	handleFile archiver.FileHandler
+	// optional step once all files are handled
+	finish func() error
+	// optional, receives the reader once all of its chunks are read and authenticated
+	finishRead func(reader *safelockReader)
+}
+
+func (sl *Safelock) extract(ctx context.Context, input io.Reader, password string, ex extraction) (err error) {
 	errs := make(chan error)
 	signals, closeSignals := utils.GetExitSignals()
 	unSubStatus := sl.StatusObs.Subscribe(sl.logStatus)
@@ -29,32 +58,74 @@ func (sl *Safelock) Decrypt(ctx context.Context, input InputReader, outputPath,
 	defer sl.StatusObs.next(StatusItem{Event: StatusEnd})
 	defer unSubStatus()
 
+	// stops the extraction from blocking on reporting errors, once they're no longer received
+	done := make(chan struct{})
+	defer close(done)
+
 	go func() {
-		if err = sl.validateDecryptionPaths(outputPath); err != nil {
-			errs <- fmt.Errorf("invalid decryption input > %w", err)
+		var err error
+
+		report := func(err error) {
+			select {
+			case errs <- err:
+			case <-done:
+			}
+		}
+
+		if ex.validate != nil {
+			if err = ex.validate(); err != nil {
+				report(fmt.Errorf("invalid decryption input > %w", err))
+				return
+			}
+		}
+
+		var pre preamble
+		var fileSl Safelock
+
+		if pre, err = readPreamble(input); err != nil {
+			report(fmt.Errorf("failed to read input preamble > %w", err))
+			return
+		}
+
+		// files carry their own settings, legacy ones fallback to the current settings
+		if fileSl, err = pre.configure(*sl); err != nil {
+			report(fmt.Errorf("invalid input preamble > %w", err))
+			return
+		}
+
+		var aead *aeadWrapper
+
+		if aead, err = newAeadReader(password, input, fileSl.EncryptionConfig, pre, errs); err != nil {
+			report(fmt.Errorf("failed to unlock input > %w", err))
 			return
 		}
 
 		ctx, cancel := context.WithCancel(ctx)
-		aead := newAeadReader(password, input, sl.EncryptionConfig, errs)
-		reader := newReader(password, input, 1.0, cancel, aead)
+		reader := newReader(password, input, pre, 1.0, cancel, aead)
 
 		if err = reader.setInputSize(); err != nil {
-			errs <- fmt.Errorf("failed to read input > %w", err)
+			report(fmt.Errorf("failed to read input > %w", err))
 			return
 		}
 
 		if err = reader.ReadHeader(); err != nil {
-			errs <- fmt.Errorf("failed to read input header > %w", err)
+			report(fmt.Errorf("failed to read input header > %w", err))
 			return
 		}
 
-		if err = sl.decryptFiles(ctx, outputPath, reader); err != nil {
-			errs <- fmt.Errorf("failed to extract archive file > %w", err)
+		if err = fileSl.decryptFiles(ctx, ex, reader); err != nil {
+			report(fmt.Errorf("failed to extract archive file > %w", err))
 			return
 		}
 
-		sl.updateStatus("All set and decrypted!", 100.0)
+		if ex.finish != nil {
+			if err = ex.finish(); err != nil {
+				report(fmt.Errorf("failed to extract archive file > %w", err))
+				return
+			}
+		}
+
+		sl.updateStatus(ex.done, 100.0)
 		close(errs)
 		closeSignals()
 	}()
@@ -73,71 +144,76 @@ func (sl *Safelock) Decrypt(ctx context.Context, input InputReader, outputPath,
 	}
 }
 
-func (sl *Safelock) validateDecryptionPaths(outputPath string) (err error) {
+func (sl *Safelock) validateDecryptionInputs(outputPath string, filter pathFilter) (err error) {
 	sl.updateStatus("Validating inputs", 0.0)
 
 	if info, err := os.Stat(outputPath); err != nil || !info.IsDir() {
 		return &slErrs.ErrInvalidOutputPath{Path: outputPath, Err: err}
 	}
 
-	return
+	return filter.validate()
 }
 
 func (sl Safelock) decryptFiles(
 	ctx context.Context,
-	outputPath string,
+	ex extraction,
 	slReader safelockReader,
 ) (err error) {
-	var reader io.ReadCloser
+	var reader io.Reader = &slReader
+	defer slReader.stop()
 
-	if reader, err = sl.Compression.OpenReader(&slReader); err != nil {
-		return fmt.Errorf("cannot read archive file > %w", err)
-	}
+	// reading errors are reported rather than the decompression or extraction errors they cause
+	defer func() {
+		if readErr := slReader.getErr(); err != nil && readErr != nil {
+			err = fmt.Errorf("cannot read archive file > %w", readErr)
+		}
+	}()
 
-	go sl.updateProgressStatus(ctx, "Decrypting", slReader)
+	if sl.Compression != nil {
+		if reader, err = sl.Compression.OpenReader(&slReader); err != nil {
+			return fmt.Errorf("cannot read archive file > %w", err)
+		}
+	}
 
-	fileHandler := getExtractFileHandler(outputPath)
+	go sl.updateProgressStatus(ctx, ex.act, &slReader)
 
-	if err = sl.Archival.Extract(ctx, reader, nil, fileHandler); err != nil {
+	if err = sl.Archival.Extract(ctx, reader, nil, ex.handleFile); err != nil {
 		return fmt.Errorf("cannot extract archive file > %w", err)
 	}
 
+	// archives can end before the encrypted content does, so authenticate what's left
+	if _, err = io.Copy(io.Discard, reader); err != nil {
+		return fmt.Errorf("cannot read archive file > %w", err)
+	}
+
+	if _, err = io.Copy(io.Discard, &slReader); err != nil {
+		return fmt.Errorf("cannot read archive file > %w", err)
+	}
+
+	if ex.finishRead != nil {
+		ex.finishRead(&slReader)
+	}
+
 	slReader.cancel()
 	return
 }
 
-func getExtractFileHandler(outputPath string) archiver.FileHandler {
-	return func(ctx context.Context, file archiver.File) (err error) {
-		var outputFile *os.File
-		var reader io.ReadCloser
-		var fullPath = filepath.Join(outputPath, file.NameInArchive)
-
-		if file.IsDir() {
-			err = os.MkdirAll(fullPath, file.Mode().Perm())
-			return
-		} else {
-			if err = os.MkdirAll(filepath.Dir(fullPath), file.Mode().Perm()); err != nil {
-				return
-			}
-		}
-
-		if reader, err = file.Open(); err != nil {
-			err = fmt.Errorf("failed to open within archive file > %w", err)
-			return
-		}
-		defer reader.Close()
+// skips files that don't match `filter`, the skipped content is still read and authenticated
+func getFilteredFileHandler(filter pathFilter, handleFile archiver.FileHandler) archiver.FileHandler {
+	if filter.isEmpty() {
+		return handleFile
+	}
 
-		if outputFile, err = os.Create(fullPath); err != nil {
-			err = fmt.Errorf("failed to create decrypted file > %w", err)
-			return
+	return func(ctx context.Context, file archiver.File) error {
+		if filter.isIncluded(file.NameInArchive) {
+			return handleFile(ctx, file)
 		}
-		defer outputFile.Close()
 
-		if _, err = io.Copy(outputFile, reader); err != nil {
-			err = fmt.Errorf("failed to write decrypted file > %w", err)
-			return
+		// no need to check what's within excluded directories
+		if file.IsDir() && filter.isExcluded(file.NameInArchive) {
+			return fs.SkipDir
 		}
 
-		return
+		return nil
 	}
 }
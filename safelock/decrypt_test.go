@@ -1,12 +1,20 @@
 package safelock_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/mrf345/safelock-cli/safelock"
 	slErrs "github.com/mrf345/safelock-cli/slErrs"
 	"github.com/stretchr/testify/assert"
 )
@@ -95,8 +103,553 @@ func TestDecryptFileWithWrongPassword(t *testing.T) {
 	assert.Nil(encErr)
 	assert.NotNil(decErr)
 	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](decErr))
+	assert.True(slErrs.Is[*slErrs.ErrNoMatchingKey](decErr))
 
 	// XXX: don't defer (temp files won't be deleted)
 	os.Remove(inputFile.Name())
 	os.RemoveAll(outputDirPath)
 }
+
+func TestDecryptFileWithDifferentSettings(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	content := "Hello World!"
+	encSl := GetQuietGzipSafelock()
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp(outputDir, "output_file.sla")
+	decryptedPath := filepath.Join(outputDir, filepath.Base(inputFile.Name()))
+	inputPaths := []string{inputFile.Name()}
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	_, _ = inputFile.WriteString(content)
+	inputFile.Close()
+
+	encSl.IterationCount = 2
+	encSl.Threads = 1
+	encSl.SaltLength = 24
+	encSl.HeaderRatio = 1024
+
+	encErr := encSl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	decrypted, _ := os.ReadFile(decryptedPath)
+
+	assert.Nil(encErr)
+	assert.Nil(decErr)
+	assert.Equal(content, string(decrypted))
+}
+
+func TestDecryptWithUnsupportedVersion(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputPath, _ := os.MkdirTemp("", "output_dir")
+	_, wErr := inputFile.Write(append([]byte("SLCK"), safelock.FormatVersion+1))
+
+	err := sl.Decrypt(context.TODO(), inputFile, outputPath, password)
+
+	assert.Nil(wErr)
+	assert.NotNil(err)
+	assert.True(slErrs.Is[*slErrs.ErrUnsupportedVersion](err))
+
+	// versions older than the current one were only created by pre-release builds
+	for version := byte(1); version < safelock.FormatVersion; version++ {
+		_ = inputFile.Truncate(0)
+		_, _ = inputFile.WriteAt(append([]byte("SLCK"), version), 0)
+
+		err = sl.Decrypt(context.TODO(), inputFile, outputPath, password)
+
+		assert.True(slErrs.Is[*slErrs.ErrUnsupportedVersion](err), version)
+		assert.ErrorContains(err, "pre-release build")
+	}
+
+	os.Remove(inputFile.Name())
+	os.RemoveAll(outputPath)
+}
+
+// decrypts a file encrypted before the format was versioned, with no preamble
+func TestDecryptBaselineFile(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.Open(filepath.Join("testdata", "baseline.sla"))
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+
+	defer inputFile.Close()
+	defer os.RemoveAll(outputDir)
+
+	// legacy files are decrypted with the current settings, and were encrypted with a single thread
+	sl.Threads = 1
+	decErr := sl.Decrypt(context.TODO(), inputFile, outputDir, password)
+	hello, _ := os.ReadFile(filepath.Join(outputDir, "baseline", "hello.txt"))
+	nested, _ := os.ReadFile(filepath.Join(outputDir, "baseline", "docs", "nested.txt"))
+	_, _ = inputFile.Seek(0, io.SeekStart)
+	wrongErr := sl.Decrypt(context.TODO(), inputFile, outputDir, "wrong_password")
+
+	assert.Nil(decErr)
+	assert.Equal("Hello World!\n", string(hello))
+	assert.Equal("Nested content\n", string(nested))
+	assert.True(slErrs.Is[*slErrs.ErrNoMatchingKey](wrongErr))
+}
+
+func TestDecryptWithExcessiveKeyDerivation(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	outputPath, _ := os.MkdirTemp("", "output_dir")
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+	defer os.RemoveAll(outputPath)
+	_, _ = inputFile.Write([]byte("content"))
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	assert.Nil(encErr)
+
+	// preamble offsets of the iteration count, memory size (alone and along with the iterations),
+	// threads and key length
+	for _, field := range []struct {
+		offset int64
+		value  []byte
+	}{
+		{5, []byte{0xff, 0xff, 0xff, 0xf0}},
+		{9, []byte{0xff, 0xff, 0xff, 0xf0}},
+		{9, []byte{0x00, 0x10, 0x00, 0x00}},
+		{13, []byte{0xff}},
+		{14, []byte{0xff, 0xff, 0xff, 0xf0}},
+		{14, []byte{0, 0, 0, 16}},
+	} {
+		original := make([]byte, len(field.value))
+		_, _ = outputFile.ReadAt(original, field.offset)
+		_, _ = outputFile.WriteAt(field.value, field.offset)
+
+		_, verifyErr := sl.Verify(context.TODO(), outputFile, password)
+		decErr := sl.Decrypt(context.TODO(), outputFile, outputPath, password)
+
+		assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](verifyErr), field.offset)
+		assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](decErr), field.offset)
+		assert.ErrorContains(decErr, "invalid file preamble parameters")
+
+		_, _ = outputFile.WriteAt(original, field.offset)
+	}
+}
+
+func TestDecryptFileFromStream(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	content := make([]byte, 1024*1024*3)
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp(outputDir, "output_file.sla")
+	decryptedPath := filepath.Join(outputDir, filepath.Base(inputFile.Name()))
+	inputPaths := []string{inputFile.Name()}
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	_, _ = rand.Read(content)
+	_, _ = inputFile.Write(content)
+	inputFile.Close()
+
+	encErr := encSl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	_, _ = outputFile.Seek(0, io.SeekStart)
+	// hides [io.Seeker] to stream the input like a pipe would
+	stream := io.MultiReader(outputFile)
+	decErr := decSl.Decrypt(context.TODO(), stream, outputDir, password)
+	decrypted, _ := os.ReadFile(decryptedPath)
+
+	assert.Nil(encErr)
+	assert.Nil(decErr)
+	assert.Equal(content, decrypted)
+}
+
+func TestDecryptSelectedFiles(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputDir, _ := os.MkdirTemp("", "input_dir")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	inputPaths := []string{inputDir}
+	decryptedDir := filepath.Join(outputDir, filepath.Base(inputDir))
+
+	defer os.RemoveAll(inputDir)
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	_ = os.MkdirAll(filepath.Join(inputDir, "docs", "nested"), 0755)
+	_ = os.MkdirAll(filepath.Join(inputDir, "other"), 0755)
+	_ = os.WriteFile(filepath.Join(inputDir, "docs", "nested", "doc.md"), []byte("doc"), 0600)
+	_ = os.WriteFile(filepath.Join(inputDir, "docs", "cache.tmp"), []byte("tmp"), 0600)
+	_ = os.WriteFile(filepath.Join(inputDir, "other", "other.md"), []byte("other"), 0600)
+
+	decSl.Include = []string{"**/docs/**"}
+	decSl.Exclude = []string{"*.tmp"}
+
+	encErr := encSl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	decrypted, _ := os.ReadFile(filepath.Join(decryptedDir, "docs", "nested", "doc.md"))
+	_, tmpErr := os.Stat(filepath.Join(decryptedDir, "docs", "cache.tmp"))
+	_, otherErr := os.Stat(filepath.Join(decryptedDir, "other"))
+
+	assert.Nil(encErr)
+	assert.Nil(decErr)
+	assert.Equal("doc", string(decrypted))
+	assert.True(os.IsNotExist(tmpErr))
+	assert.True(os.IsNotExist(otherErr))
+}
+
+func TestDecryptWithInvalidPattern(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputPath, _ := os.MkdirTemp("", "output_dir")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputPath)
+
+	sl.Include = []string{"[invalid"}
+	err := sl.Decrypt(context.TODO(), inputFile, outputPath, password)
+
+	assert.NotNil(err)
+	assert.ErrorContains(err, "invalid glob pattern")
+}
+
+func TestDecryptWithUnsafePath(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	extractDir := filepath.Join(outputDir, "extract")
+	outputFile, _ := os.CreateTemp(outputDir, "output_file.sla")
+	inputPaths := []string{inputFile.Name()}
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	_ = os.Mkdir(extractDir, 0755)
+
+	for _, name := range []string{"../escaped.txt", "/escaped.txt", "nested/../../escaped.txt"} {
+		_ = outputFile.Truncate(0)
+		_, _ = outputFile.Seek(0, io.SeekStart)
+		encSl := GetQuietRenamingSafelock(name)
+		encErr := encSl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+		decErr := decSl.Decrypt(context.TODO(), outputFile, extractDir, password)
+		_, statErr := os.Stat(filepath.Join(outputDir, "escaped.txt"))
+
+		assert.Nil(encErr)
+		assert.True(slErrs.Is[*slErrs.ErrUnsafePath](decErr), name)
+		assert.True(os.IsNotExist(statErr), name)
+	}
+}
+
+func TestDecryptThroughSymlink(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	encSl := GetQuietRenamingSafelock("link/escaped.txt")
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outsideDir, _ := os.MkdirTemp("", "outside_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	inputPaths := []string{inputFile.Name()}
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(outsideDir)
+
+	if err := os.Symlink(outsideDir, filepath.Join(outputDir, "link")); err != nil {
+		t.Skip("symbolic links are not supported", err)
+	}
+
+	encErr := encSl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	_, statErr := os.Stat(filepath.Join(outsideDir, "escaped.txt"))
+
+	assert.Nil(encErr)
+	assert.True(slErrs.Is[*slErrs.ErrUnsafePath](decErr))
+	assert.True(os.IsNotExist(statErr))
+}
+
+func TestDecryptDirectoryOverSymlink(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputDir, _ := os.MkdirTemp("", "input_dir")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outsideDir, _ := os.MkdirTemp("", "outside_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	linkPath := filepath.Join(inputDir, "a_link")
+	dirPath := filepath.Join(inputDir, "b_dir")
+
+	defer os.RemoveAll(inputDir)
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(outsideDir)
+	defer os.Remove(outputFile.Name())
+	_ = os.Chmod(outsideDir, 0700)
+	_ = os.Mkdir(dirPath, 0755)
+	_ = os.Chmod(dirPath, 0777)
+
+	if err := os.Symlink(outsideDir, linkPath); err != nil {
+		t.Skip("symbolic links are not supported", err)
+	}
+
+	// the link gets archived first, so the directory of the same name would be created through it
+	inputPaths := map[string]string{linkPath: "d", dirPath: "d"}
+	encErr := encSl.EncryptNamed(context.TODO(), inputPaths, outputFile, password)
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	outsideInfo, _ := os.Stat(outsideDir)
+
+	assert.Nil(encErr)
+	assert.True(slErrs.Is[*slErrs.ErrUnsafePath](decErr))
+	assert.Equal(os.FileMode(0700), outsideInfo.Mode().Perm())
+}
+
+func TestDecryptPreservesAttributes(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputDir, _ := os.MkdirTemp("", "input_dir")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	inputPaths := []string{inputDir}
+	innerDir := filepath.Join(inputDir, "inner")
+	innerFile := filepath.Join(innerDir, "file.txt")
+	decryptedDir := filepath.Join(outputDir, filepath.Base(inputDir), "inner")
+	decryptedFile := filepath.Join(decryptedDir, "file.txt")
+
+	defer os.RemoveAll(inputDir)
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	_ = os.Mkdir(innerDir, 0700)
+	_ = os.WriteFile(innerFile, []byte("content"), 0640)
+	_ = os.Chtimes(innerFile, modTime, modTime)
+	_ = os.Chtimes(innerDir, modTime, modTime)
+
+	encErr := encSl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	fileInfo, _ := os.Stat(decryptedFile)
+	dirInfo, _ := os.Stat(decryptedDir)
+
+	assert.Nil(encErr)
+	assert.Nil(decErr)
+	assert.True(modTime.Equal(fileInfo.ModTime()))
+	assert.True(modTime.Equal(dirInfo.ModTime()))
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(os.FileMode(0640), fileInfo.Mode().Perm())
+		assert.Equal(os.FileMode(0700), dirInfo.Mode().Perm())
+	}
+}
+
+func TestDecryptWithoutPreservingAttributes(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp(outputDir, "output_file.sla")
+	inputPaths := []string{inputFile.Name()}
+	decryptedPath := filepath.Join(outputDir, filepath.Base(inputFile.Name()))
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	inputFile.Close()
+	_ = os.Chtimes(inputFile.Name(), modTime, modTime)
+
+	decSl.PreservePermissions = false
+	decSl.PreserveTimes = false
+
+	encErr := encSl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	info, _ := os.Stat(decryptedPath)
+
+	assert.Nil(encErr)
+	assert.Nil(decErr)
+	assert.False(modTime.Equal(info.ModTime()))
+}
+
+func TestDecryptWithConflictPolicies(t *testing.T) {
+	password := "testing123456"
+	content := "decrypted"
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	encSl := GetQuietSafelock()
+	inputDir, _ := os.MkdirTemp("", "input_dir")
+	inputFile := filepath.Join(inputDir, "file.txt")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.RemoveAll(inputDir)
+	defer os.Remove(outputFile.Name())
+	_ = os.WriteFile(inputFile, []byte(content), 0644)
+	_ = os.Chtimes(inputFile, modTime, modTime)
+	assert.Nil(t, encSl.Encrypt(context.TODO(), []string{inputFile}, outputFile, password))
+
+	cases := []struct {
+		policy       safelock.ConflictPolicy
+		existingTime time.Time
+		content      string
+		renamed      bool
+		failed       bool
+	}{
+		{policy: safelock.ConflictOverwrite, existingTime: modTime, content: content},
+		{policy: safelock.ConflictSkip, existingTime: modTime, content: "existing"},
+		{policy: safelock.ConflictRename, existingTime: modTime, content: "existing", renamed: true},
+		{policy: safelock.ConflictFail, existingTime: modTime, content: "existing", failed: true},
+		{policy: safelock.ConflictNewer, existingTime: modTime.AddDate(-1, 0, 0), content: content},
+		{policy: safelock.ConflictNewer, existingTime: modTime.AddDate(1, 0, 0), content: "existing"},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.policy), func(t *testing.T) {
+			var conflicts atomic.Int32
+			assert := assert.New(t)
+			decSl := GetQuietSafelock()
+			outputDir, _ := os.MkdirTemp("", "output_dir")
+			existingFile := filepath.Join(outputDir, "file.txt")
+
+			defer os.RemoveAll(outputDir)
+			_ = os.WriteFile(existingFile, []byte("existing"), 0644)
+			_ = os.Chtimes(existingFile, c.existingTime, c.existingTime)
+
+			decSl.OnConflict = c.policy
+			decSl.StatusObs.Subscribe(func(status safelock.StatusItem) {
+				if status.Event == safelock.StatusConflict && status.Path == existingFile {
+					conflicts.Add(1)
+				}
+			})
+
+			err := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+			existing, _ := os.ReadFile(existingFile)
+			renamed, renamedErr := os.ReadFile(filepath.Join(outputDir, "file_1.txt"))
+
+			assert.Equal(c.failed, slErrs.Is[*slErrs.ErrFileConflict](err))
+			assert.Equal(c.content, string(existing))
+			assert.Equal(c.renamed, renamedErr == nil)
+			assert.Eventually(func() bool { return conflicts.Load() == 1 }, time.Second, time.Millisecond*10)
+
+			if c.renamed {
+				assert.Equal(content, string(renamed))
+			}
+		})
+	}
+}
+
+func TestDecryptWithRemovedChunks(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	content := make([]byte, 3*1024*1024)
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	truncatedFile, _ := os.CreateTemp("", "truncated_file.sla")
+	outputPath, _ := os.MkdirTemp("", "output_dir")
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+	defer os.Remove(truncatedFile.Name())
+	defer os.RemoveAll(outputPath)
+
+	_, _ = rand.Read(content)
+	_, _ = inputFile.Write(content)
+	inputFile.Close()
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	encrypted, _ := os.ReadFile(outputFile.Name())
+
+	// preamble and key slots size, followed by the size prefixed chunks frames
+	var frames []int
+	offset := 26 + 8*128
+
+	for size := 1; size != 0; offset += 4 + size {
+		size = int(binary.BigEndian.Uint32(encrypted[offset:]))
+		frames = append(frames, offset)
+	}
+
+	// drop the last chunks, keeping the end frame and the original header after them
+	endFrame := frames[len(frames)-1]
+	truncated := append(append([]byte{}, encrypted[:frames[len(frames)-3]]...), encrypted[endFrame:]...)
+	_, _ = truncatedFile.Write(truncated)
+
+	decErr := sl.Decrypt(context.TODO(), truncatedFile, outputPath, password)
+
+	assert.Nil(encErr)
+	assert.Greater(len(frames), 3)
+	assert.True(slErrs.Is[*slErrs.ErrTruncatedInput](decErr))
+}
+
+func TestDecryptWithOversizedHeader(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	outputPath, _ := os.MkdirTemp("", "output_dir")
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+	defer os.RemoveAll(outputPath)
+	_, _ = inputFile.Write([]byte("content"))
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	_, _ = outputFile.Seek(0, io.SeekEnd)
+	_, _ = outputFile.Write(make([]byte, 1024*1024))
+	_, _ = outputFile.Seek(0, io.SeekStart)
+	// hides [io.Seeker] to stream the input like a pipe would
+	decErr := sl.Decrypt(context.TODO(), io.MultiReader(outputFile), outputPath, password)
+	_, _ = outputFile.Seek(0, io.SeekStart)
+	_, inspectErr := sl.Inspect(outputFile)
+	_, metadataErr := sl.ReadMetadata(context.TODO(), outputFile, password)
+
+	assert.Nil(encErr)
+
+	for _, err := range []error{decErr, inspectErr, metadataErr} {
+		assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](err))
+		assert.ErrorContains(err, "header is bigger")
+	}
+}
+
+func TestDecryptWithReadAhead(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	content := make([]byte, 5*1024*1024+123)
+	encSl := GetQuietSafelock()
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(outputFile.Name())
+
+	_, _ = rand.Read(content)
+	encErr := encSl.EncryptReader(context.TODO(), bytes.NewReader(content), "stream.bin", outputFile, password)
+	encrypted, _ := os.ReadFile(outputFile.Name())
+
+	assert.Nil(encErr)
+
+	for _, config := range []struct{ workers, readAhead int }{{1, 1}, {4, 1}, {2, 8}, {8, 3}} {
+		decSl := GetQuietSafelock()
+		decSl.Workers = config.workers
+		decSl.ReadAhead = config.readAhead
+		outputPath, _ := os.MkdirTemp("", "output_dir")
+
+		decErr := decSl.Decrypt(context.TODO(), bytes.NewReader(encrypted), outputPath, password)
+		decrypted, _ := os.ReadFile(filepath.Join(outputPath, "stream.bin"))
+		_, truncatedErr := decSl.Verify(context.TODO(), bytes.NewReader(encrypted[:len(encrypted)/2]), password)
+
+		assert.Nil(decErr, config)
+		assert.Equal(content, decrypted, config)
+		assert.True(slErrs.Is[*slErrs.ErrTruncatedInput](truncatedErr), config)
+
+		os.RemoveAll(outputPath)
+	}
+}
@@ -0,0 +1,238 @@
+package safelock
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mholt/archiver/v4"
+	"github.com/mrf345/safelock-cli/utils"
+)
+
+// how symbolic links are archived on encryption
+type LinksPolicy string
+
+const (
+	LinksStore  LinksPolicy = "store"  // archive links as links
+	LinksFollow LinksPolicy = "follow" // archive what links point to, dangling or looping links are stored
+	LinksSkip   LinksPolicy = "skip"   // leave links out
+)
+
+// lists files on disk to be archived
+type diskLister struct {
+	links LinksPolicy
+	files []archiver.File
+	// include and exclude patterns, including the ones read from ignore files
+	filter     pathFilter
+	ignoreFile string
+	// archived names of hard linked files by their ids
+	hardLinks map[fileId]string
+	// real paths of the directories being walked, to break links loops
+	walking map[string]bool
+}
+
+// unique id of a file on disk
+type fileId struct {
+	dev, ino uint64
+}
+
+func newDiskLister(config ArchiverConfig) *diskLister {
+	// ignore files patterns get appended, so they shouldn't change the config ones
+	config.EncryptExclude = slices.Clip(config.EncryptExclude)
+
+	return &diskLister{
+		links:      config.Links,
+		filter:     config.getEncryptFilter(),
+		ignoreFile: config.IgnoreFile,
+		hardLinks:  make(map[fileId]string),
+		walking:    make(map[string]bool),
+	}
+}
+
+// adds `rootOnDisk` and all its content named after `rootInArchive`, which defaults to the base name
+// of `rootOnDisk` if empty, or to only its content if `rootOnDisk` ends with a path separator
+func (dl *diskLister) add(rootOnDisk, rootInArchive string) (err error) {
+	if info, statErr := os.Lstat(rootOnDisk); statErr == nil && info.IsDir() {
+		if realPath, evalErr := filepath.EvalSymlinks(rootOnDisk); evalErr == nil {
+			dl.walking[realPath] = true
+			defer delete(dl.walking, realPath)
+		}
+	}
+
+	return filepath.WalkDir(rootOnDisk, func(filename string, entry fs.DirEntry, err error) error {
+		var info fs.FileInfo
+
+		if err != nil {
+			return err
+		}
+
+		if info, err = entry.Info(); err != nil {
+			return err
+		}
+
+		name := getNameInArchive(filename, rootOnDisk, rootInArchive)
+
+		if dl.filter.isExcluded(name) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if entry.IsDir() {
+			if err = dl.readIgnoreFile(filename, name); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case name == "":
+			// root directory whose content only is archived
+			return nil
+		case !dl.filter.isIncluded(name):
+			// directories are still walked for included content
+			return nil
+		case info.Mode()&fs.ModeSocket != 0:
+			// sockets can't be archived
+			return nil
+		case info.Mode()&fs.ModeSymlink != 0:
+			return dl.addLink(filename, name, info)
+		}
+
+		dl.addFile(filename, name, info)
+		return nil
+	})
+}
+
+// adds the patterns of `dirPath` ignore file if any, scoped to the directory archive `name`
+func (dl *diskLister) readIgnoreFile(dirPath, name string) (err error) {
+	var patterns []string
+
+	if dl.ignoreFile == "" {
+		return
+	}
+
+	ignorePath := filepath.Join(dirPath, dl.ignoreFile)
+
+	if patterns, err = utils.ReadPatterns(ignorePath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read ignore file (%s) > %w", ignorePath, err)
+	}
+
+	for _, pattern := range patterns {
+		// patterns with a slash are relative to the directory, others match at any depth within it
+		anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+		pattern = strings.Trim(pattern, "/")
+
+		if anchored {
+			pattern = path.Join(name, pattern)
+		} else if name != "" {
+			pattern = name + "/**/" + pattern
+		}
+
+		dl.filter.exclude = append(dl.filter.exclude, pattern)
+	}
+
+	return
+}
+
+// keeps archive names relative, and their trailing slash
+func cleanArchiveName(name string) (cleaned string) {
+	if cleaned = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/"); cleaned == "" {
+		return
+	}
+
+	if strings.HasSuffix(filepath.ToSlash(name), "/") {
+		cleaned += "/"
+	}
+
+	return
+}
+
+func getNameInArchive(nameOnDisk, rootOnDisk, rootInArchive string) string {
+	if rootInArchive == "" && !strings.HasSuffix(rootOnDisk, string(filepath.Separator)) {
+		rootInArchive = filepath.Base(rootOnDisk)
+	} else if strings.HasSuffix(rootInArchive, "/") {
+		rootInArchive += filepath.Base(rootOnDisk)
+	}
+
+	relativePath := strings.TrimPrefix(nameOnDisk, rootOnDisk)
+	return path.Join(rootInArchive, filepath.ToSlash(relativePath))
+}
+
+func (dl *diskLister) addLink(filename, name string, info fs.FileInfo) (err error) {
+	var target, realPath string
+	var realInfo fs.FileInfo
+
+	switch dl.links {
+	case LinksSkip:
+		return
+	case LinksFollow:
+		if realPath, err = filepath.EvalSymlinks(filename); err == nil && !dl.walking[realPath] {
+			if realInfo, err = os.Stat(realPath); err == nil {
+				if realInfo.IsDir() {
+					return dl.add(realPath, name)
+				}
+
+				dl.addFile(realPath, name, realInfo)
+				return
+			}
+		}
+	}
+
+	// dangling and looping links can't be followed, so they're stored as links
+	if target, err = os.Readlink(filename); err != nil {
+		return fmt.Errorf("failed to read link (%s) > %w", filename, err)
+	}
+
+	dl.files = append(dl.files, archiver.File{
+		FileInfo:      info,
+		NameInArchive: name,
+		LinkTarget:    target,
+	})
+
+	return
+}
+
+func (dl *diskLister) addFile(filename, name string, info fs.FileInfo) {
+	if id, ok := getFileId(info); ok && info.Mode().IsRegular() {
+		if linkName, linked := dl.hardLinks[id]; linked {
+			info = newHardLinkInfo(info, linkName)
+		} else {
+			dl.hardLinks[id] = name
+		}
+	}
+
+	dl.files = append(dl.files, archiver.File{
+		FileInfo:      info,
+		NameInArchive: name,
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(filename)
+		},
+	})
+}
+
+// file info that gets archived as a hard link to an already archived file
+type hardLinkInfo struct {
+	fs.FileInfo
+	header *tar.Header
+}
+
+func newHardLinkInfo(info fs.FileInfo, linkName string) hardLinkInfo {
+	header, _ := tar.FileInfoHeader(info, "")
+	header.Typeflag = tar.TypeLink
+	header.Linkname = linkName
+	return hardLinkInfo{FileInfo: info, header: header}
+}
+
+func (hi hardLinkInfo) Size() int64 { return 0 }
+func (hi hardLinkInfo) Sys() any    { return hi.header }
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package safelock
+
+import "io/fs"
+
+// hard links are not detected on this platform
+func getFileId(info fs.FileInfo) (id fileId, ok bool) {
+	return
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package safelock
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// gets the id of files with multiple hard links
+func getFileId(info fs.FileInfo) (id fileId, ok bool) {
+	if stat, isStat := info.Sys().(*syscall.Stat_t); isStat && stat.Nlink > 1 {
+		return fileId{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+	}
+
+	return
+}
@@ -2,9 +2,11 @@ package safelock
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/mholt/archiver/v4"
@@ -12,15 +14,75 @@ import (
 	"github.com/mrf345/safelock-cli/utils"
 )
 
+// lists the files to be archived and encrypted
+type filesLister func() ([]archiver.File, error)
+
 // encrypts `inputPaths` which can be either a slice of file or directory paths and then
 // outputs into an object `output` that implements [io.Writer] such as [io.File]
 //
 // NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
 func (sl *Safelock) Encrypt(ctx context.Context, inputPaths []string, output io.Writer, password string) (err error) {
-	errs := make(chan error)
-	go sl.loadRandom(errs)
-	aead := newAeadWriter(password, output, sl.EncryptionConfig, errs)
-	signals, closeSignals := utils.GetExitSignals()
+	return sl.encrypt(ctx, inputPaths, output, password, func() ([]archiver.File, error) {
+		return sl.listDiskFiles(inputPaths, nil)
+	})
+}
+
+// encrypts the file or directory paths keys of `inputPaths` like [safelock.Safelock.Encrypt], naming
+// each within the encrypted file after its value, empty names default to the paths base names and
+// names ending with a slash place the paths within a directory of that name
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) EncryptNamed(
+	ctx context.Context,
+	inputPaths map[string]string,
+	output io.Writer,
+	password string,
+) (err error) {
+	// copy of the paths to sort, so they're encrypted in the same order without changing `inputPaths`
+	paths := make([]string, 0, len(inputPaths))
+
+	for path := range inputPaths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return sl.encrypt(ctx, paths, output, password, func() ([]archiver.File, error) {
+		return sl.listDiskFiles(paths, inputPaths)
+	})
+}
+
+// encrypts `input` stream such as [os.Stdin] as a single file named `name` and then
+// outputs into an object `output` that implements [io.Writer] such as [os.Stdout]
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) EncryptReader(
+	ctx context.Context,
+	input io.Reader,
+	name string,
+	output io.Writer,
+	password string,
+) (err error) {
+	streamSl := *sl
+	streamSl.Archival = streamArchival{}
+	file := newStreamFile(input, name)
+
+	return streamSl.encrypt(ctx, nil, output, password, func() ([]archiver.File, error) {
+		if file.NameInArchive == "" {
+			return nil, &slErrs.ErrInvalidInputPath{Path: name, Err: fmt.Errorf("empty stream name")}
+		}
+
+		return []archiver.File{file}, nil
+	})
+}
+
+func (sl *Safelock) encrypt(
+	ctx context.Context,
+	inputPaths []string,
+	output io.Writer,
+	password string,
+	listFiles filesLister,
+) (err error) {
 	unSubStatus := sl.StatusObs.Subscribe(sl.logStatus)
 
 	if ctx == nil {
@@ -31,22 +93,44 @@ func (sl *Safelock) Encrypt(ctx context.Context, inputPaths []string, output io.
 	defer sl.StatusObs.next(StatusItem{Event: StatusEnd})
 	defer unSubStatus()
 
+	// validated before the key slots are created and written, which rely on valid settings
+	if err = sl.validateEncryptionInputs(inputPaths, password); err != nil {
+		err = fmt.Errorf("invalid encryption input > %w", err)
+		sl.StatusObs.next(StatusItem{Event: StatusError, Err: err})
+		return
+	}
+
+	errs := make(chan error)
+	go sl.loadRandom(errs)
+	pre := newPreamble(sl.EncryptionConfig, sl.ArchiverConfig)
+	aead := newAeadWriter(password, output, sl.EncryptionConfig, pre, errs)
+	signals, closeSignals := utils.GetExitSignals()
+
+	// stops the encryption from blocking on reporting errors, once they're no longer received
+	done := make(chan struct{})
+	defer close(done)
+
 	go func() {
-		if err = sl.validateEncryptionInputs(inputPaths, password); err != nil {
-			errs <- fmt.Errorf("invalid encryption input > %w", err)
-			return
+		var err error
+
+		report := func(err error) {
+			select {
+			case errs <- err:
+			case <-done:
+			}
 		}
 
 		ctx, cancel := context.WithCancel(ctx)
 		writer := newWriter(password, output, 20.0, cancel, aead)
+		defer writer.close()
 
-		if err = sl.encryptFiles(ctx, inputPaths, writer); err != nil {
-			errs <- err
+		if err = sl.encryptFiles(ctx, listFiles, writer); err != nil {
+			report(err)
 			return
 		}
 
 		if err = writer.WriteHeader(); err != nil {
-			errs <- fmt.Errorf("failed to create encrypted file header > %w", err)
+			report(fmt.Errorf("failed to create encrypted file header > %w", err))
 			return
 		}
 
@@ -78,41 +162,72 @@ func (sl Safelock) validateEncryptionInputs(inputPaths []string, pwd string) (er
 		}
 	}
 
-	if len(pwd) < sl.MinPasswordLength {
+	if err = sl.getEncryptFilter().validate(); err != nil {
+		return
+	}
+
+	// the password can be left out when encrypting with a keyfile or to recipients only
+	if len(pwd) < sl.MinPasswordLength && (pwd != "" || !sl.hasKeyfile() && len(sl.Recipients) == 0) {
 		return &slErrs.ErrInvalidPassword{Len: len(pwd), Need: sl.MinPasswordLength}
 	}
 
+	if err = sl.checkPasswordPolicy(pwd); err != nil {
+		return
+	}
+
+	if sl.SaltLength < 1 || sl.SaltLength > maxSaltLength {
+		return fmt.Errorf("salt length (%d) must be between 1 and %d", sl.SaltLength, maxSaltLength)
+	}
+
+	if err = validateKeyDerivation(sl.IterationCount, sl.MemSize, sl.Threads, sl.KeyLength); err != nil {
+		return
+	}
+
+	if sl.Metadata != nil {
+		if encoded, _ := json.Marshal(sl.Metadata); len(encoded) > maxMetadataSize {
+			return fmt.Errorf("metadata size (%d) exceeds the maximum (%d)", len(encoded), maxMetadataSize)
+		}
+	}
+
 	return
 }
 
+func (sl Safelock) listDiskFiles(inputPaths []string, names map[string]string) (files []archiver.File, err error) {
+	lister := newDiskLister(sl.ArchiverConfig)
+
+	for _, path := range inputPaths {
+		if err = lister.add(path, cleanArchiveName(names[path])); err != nil {
+			return
+		}
+	}
+
+	return lister.files, nil
+}
+
 func (sl Safelock) encryptFiles(
 	ctx context.Context,
-	inputPaths []string,
-	slWriter safelockWriter,
+	listFiles filesLister,
+	slWriter *safelockWriter,
 ) (err error) {
 	var files []archiver.File
-	var filesMap = make(map[string]string, len(inputPaths))
 	var cancelListingStatus = sl.updateListingStatus(ctx, 1.0, slWriter.start)
 
-	for _, path := range inputPaths {
-		filesMap[path] = ""
-	}
-
-	if files, err = archiver.FilesFromDisk(nil, filesMap); err != nil {
+	if files, err = listFiles(); err != nil {
 		err = fmt.Errorf("failed to read and list input paths > %w", err)
 		return
 	}
 
-	go func() {
-		for _, file := range files {
-			slWriter.increaseInputSize(int(file.Size()))
-		}
+	// summed before archiving, which can change the files
+	for _, file := range files {
+		slWriter.increaseInputSize(int(file.Size()))
+	}
 
+	go func() {
 		cancelListingStatus()
 		sl.updateProgressStatus(ctx, "Encrypting", slWriter)
 	}()
 
-	if err = sl.archive(ctx, &slWriter, files); err != nil {
+	if err = sl.archive(ctx, slWriter, files); err != nil {
 		err = fmt.Errorf("failed to create encrypted archive file > %w", err)
 		return
 	}
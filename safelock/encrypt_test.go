@@ -1,13 +1,19 @@
 package safelock_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/mrf345/safelock-cli/safelock"
 	slErrs "github.com/mrf345/safelock-cli/slErrs"
 	"github.com/stretchr/testify/assert"
 )
@@ -105,3 +111,324 @@ func TestEncryptFileWithTimeout(t *testing.T) {
 	os.Remove(inputFile.Name())
 	os.RemoveAll(outputDir)
 }
+
+func TestEncryptReader(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	content := "Hello World!"
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp(outputDir, "output_file.sla")
+	decryptedPath := filepath.Join(outputDir, "streams", "stream.txt")
+
+	defer os.RemoveAll(outputDir)
+
+	inErr := encSl.EncryptReader(context.TODO(), strings.NewReader(content), "streams/stream.txt", outputFile, password)
+	outErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	decrypted, _ := os.ReadFile(decryptedPath)
+
+	assert.Nil(inErr)
+	assert.Nil(outErr)
+	assert.Equal(content, string(decrypted))
+}
+
+func TestEncryptReaderWithWorkers(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	content := make([]byte, 5*1024*1024+123)
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp(outputDir, "output_file.sla")
+
+	defer os.RemoveAll(outputDir)
+
+	_, _ = rand.Read(content)
+	encSl.Workers = 4
+	inErr := encSl.EncryptReader(context.TODO(), bytes.NewReader(content), "stream.bin", outputFile, password)
+	_, _ = outputFile.Seek(0, io.SeekStart)
+	report, verifyErr := decSl.Verify(context.TODO(), outputFile, password)
+	_, _ = outputFile.Seek(0, io.SeekStart)
+	outErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	decrypted, _ := os.ReadFile(filepath.Join(outputDir, "stream.bin"))
+
+	assert.Nil(inErr)
+	assert.Nil(verifyErr)
+	assert.Nil(outErr)
+	assert.Less(4, report.Chunks)
+	assert.Equal(content, decrypted)
+}
+
+// accepts the first write only, failing the ones after it
+type failingWriter struct {
+	writes int
+}
+
+func (fw *failingWriter) Write(data []byte) (int, error) {
+	if fw.writes += 1; fw.writes > 1 {
+		return 0, errors.New("output failed")
+	}
+
+	return len(data), nil
+}
+
+// counts the bytes read from the underlying reader
+type countingReader struct {
+	io.Reader
+	read atomic.Int64
+}
+
+func (cr *countingReader) Read(data []byte) (read int, err error) {
+	read, err = cr.Reader.Read(data)
+	cr.read.Add(int64(read))
+	return
+}
+
+func TestEncryptStopsOnOutputError(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	size := int64(64 * 1024 * 1024)
+	sl := GetQuietSafelock()
+	input := &countingReader{Reader: io.LimitReader(rand.Reader, size)}
+
+	err := sl.EncryptReader(context.TODO(), input, "stream.bin", &failingWriter{}, password)
+
+	assert.ErrorContains(err, "output failed")
+	assert.Less(input.read.Load(), size/2)
+}
+
+// creates a directory with a file, a directory, links to both, a dangling link,
+// a link looping back to the directory and a hard link
+func createLinkedDir(t *testing.T) (inputDir string) {
+	inputDir, _ = os.MkdirTemp("", "input_dir")
+	_ = os.WriteFile(filepath.Join(inputDir, "file.txt"), []byte("Hello World!"), 0644)
+	_ = os.Mkdir(filepath.Join(inputDir, "dir"), 0755)
+	_ = os.WriteFile(filepath.Join(inputDir, "dir", "nested.txt"), []byte("Nested!"), 0644)
+	_ = os.Link(filepath.Join(inputDir, "file.txt"), filepath.Join(inputDir, "hard.txt"))
+
+	links := map[string]string{
+		"file_link": "file.txt",
+		"dir_link":  "dir",
+		"dangling":  "missing.txt",
+		"loop":      ".",
+	}
+
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(inputDir, name)); err != nil {
+			os.RemoveAll(inputDir)
+			t.Skipf("symbolic links are not supported > %s", err)
+		}
+	}
+
+	return
+}
+
+func encryptAndDecryptLinkedDir(t *testing.T, links safelock.LinksPolicy) (decryptedDir string) {
+	password := "testing123456"
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputDir := createLinkedDir(t)
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	t.Cleanup(func() {
+		os.RemoveAll(inputDir)
+		os.RemoveAll(outputDir)
+		os.Remove(outputFile.Name())
+	})
+
+	encSl.Links = links
+	inErr := encSl.Encrypt(context.TODO(), []string{inputDir}, outputFile, password)
+	outErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+
+	assert.Nil(t, inErr)
+	assert.Nil(t, outErr)
+	return filepath.Join(outputDir, filepath.Base(inputDir))
+}
+
+func TestEncryptStoringLinks(t *testing.T) {
+	assert := assert.New(t)
+	decryptedDir := encryptAndDecryptLinkedDir(t, safelock.LinksStore)
+	fileInfo, _ := os.Stat(filepath.Join(decryptedDir, "file.txt"))
+	hardInfo, _ := os.Stat(filepath.Join(decryptedDir, "hard.txt"))
+
+	for name, target := range map[string]string{
+		"file_link": "file.txt",
+		"dir_link":  "dir",
+		"dangling":  "missing.txt",
+		"loop":      ".",
+	} {
+		linked, err := os.Readlink(filepath.Join(decryptedDir, name))
+
+		assert.Nil(err)
+		assert.Equal(target, linked)
+	}
+
+	if runtime.GOOS != "windows" {
+		assert.True(os.SameFile(fileInfo, hardInfo))
+	}
+}
+
+func TestEncryptFollowingLinks(t *testing.T) {
+	assert := assert.New(t)
+	decryptedDir := encryptAndDecryptLinkedDir(t, safelock.LinksFollow)
+	fileContent, _ := os.ReadFile(filepath.Join(decryptedDir, "file_link"))
+	nestedContent, _ := os.ReadFile(filepath.Join(decryptedDir, "dir_link", "nested.txt"))
+	fileInfo, _ := os.Lstat(filepath.Join(decryptedDir, "file_link"))
+	danglingTarget, danglingErr := os.Readlink(filepath.Join(decryptedDir, "dangling"))
+	loopTarget, loopErr := os.Readlink(filepath.Join(decryptedDir, "loop"))
+
+	assert.True(fileInfo.Mode().IsRegular())
+	assert.Equal("Hello World!", string(fileContent))
+	assert.Equal("Nested!", string(nestedContent))
+	assert.Nil(danglingErr)
+	assert.Equal("missing.txt", danglingTarget)
+	assert.Nil(loopErr)
+	assert.Equal(".", loopTarget)
+}
+
+func TestEncryptSkippingLinks(t *testing.T) {
+	assert := assert.New(t)
+	decryptedDir := encryptAndDecryptLinkedDir(t, safelock.LinksSkip)
+
+	for _, name := range []string{"file_link", "dir_link", "dangling", "loop"} {
+		_, err := os.Lstat(filepath.Join(decryptedDir, name))
+		assert.True(os.IsNotExist(err))
+	}
+
+	content, _ := os.ReadFile(filepath.Join(decryptedDir, "file.txt"))
+	assert.Equal("Hello World!", string(content))
+}
+
+func TestEncryptNamedPaths(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputDir, _ := os.MkdirTemp("", "input_dir")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	inputFile := filepath.Join(inputDir, "file.txt")
+	innerDir := filepath.Join(inputDir, "inner")
+
+	defer os.RemoveAll(inputDir)
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	_ = os.WriteFile(inputFile, []byte("file"), 0644)
+	_ = os.Mkdir(innerDir, 0755)
+	_ = os.WriteFile(filepath.Join(innerDir, "nested.txt"), []byte("nested"), 0644)
+
+	inErr := encSl.EncryptNamed(context.TODO(), map[string]string{
+		inputFile: "../renamed.txt",
+		innerDir:  "docs/",
+	}, outputFile, password)
+	outErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	renamed, _ := os.ReadFile(filepath.Join(outputDir, "renamed.txt"))
+	nested, _ := os.ReadFile(filepath.Join(outputDir, "docs", "inner", "nested.txt"))
+
+	assert.Nil(inErr)
+	assert.Nil(outErr)
+	assert.Equal("file", string(renamed))
+	assert.Equal("nested", string(nested))
+}
+
+func TestEncryptWithFilters(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputDir, _ := os.MkdirTemp("", "input_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	files := map[string]string{
+		"node_modules/lib/index.js": "",
+		"src/main.go":               "",
+		"src/debug.log":             "",
+		"src/build/main":            "",
+		"build/main":                "",
+		"notes.txt":                 "",
+		".safelockignore":           "# logs\n*.log\n",
+		"src/.safelockignore":       "/build\n",
+	}
+
+	defer os.RemoveAll(inputDir)
+	defer os.Remove(outputFile.Name())
+
+	for name, content := range files {
+		fullPath := filepath.Join(inputDir, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(fullPath), 0755)
+		_ = os.WriteFile(fullPath, []byte(content), 0644)
+	}
+
+	sl.EncryptExclude = []string{"node_modules", "*.txt"}
+	encErr := sl.Encrypt(context.TODO(), []string{inputDir + string(filepath.Separator)}, outputFile, password)
+	entries, listErr := sl.List(context.TODO(), outputFile, password)
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+
+	assert.Nil(encErr)
+	assert.Nil(listErr)
+	assert.ElementsMatch([]string{
+		".safelockignore",
+		"build",
+		"build/main",
+		"src",
+		"src/.safelockignore",
+		"src/main.go",
+	}, names)
+}
+
+func TestEncryptWithInvalidPattern(t *testing.T) {
+	assert := assert.New(t)
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+
+	sl.EncryptInclude = []string{"[invalid"}
+	err := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, "testing123456")
+
+	assert.NotNil(err)
+}
+
+func TestEncryptWithInvalidSaltLength(t *testing.T) {
+	assert := assert.New(t)
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+
+	defer os.Remove(inputFile.Name())
+
+	for _, length := range []int{0, 40} {
+		output := &bytes.Buffer{}
+		sl.SaltLength = length
+		err := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, output, "testing123456")
+
+		assert.ErrorContains(err, "salt length", length)
+		assert.Zero(output.Len(), length)
+	}
+}
+
+func TestEncryptWithExcessiveKeyDerivation(t *testing.T) {
+	assert := assert.New(t)
+	inputFile, _ := os.CreateTemp("", "input_file")
+
+	defer os.Remove(inputFile.Name())
+
+	for _, configure := range []func(*safelock.Safelock){
+		func(sl *safelock.Safelock) { sl.IterationCount = 100 },
+		func(sl *safelock.Safelock) { sl.MemSize = 8 * 1024 * 1024 },
+		func(sl *safelock.Safelock) { sl.IterationCount, sl.MemSize = 16, 1024*1024 },
+	} {
+		sl := GetQuietSafelock()
+		output := &bytes.Buffer{}
+		configure(sl)
+		err := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, output, "testing123456")
+
+		assert.ErrorContains(err, "invalid encryption input")
+		assert.Zero(output.Len())
+	}
+}
@@ -11,6 +11,8 @@ const (
 	StatusEnd    StatusEvent = "end_status"    // encryption/decryption has ended
 	StatusUpdate StatusEvent = "update_status" // new status update
 	StatusError  StatusEvent = "error_status"  // encryption/decryption failed
+
+	StatusConflict StatusEvent = "conflict_status" // decrypted file already exists
 )
 
 // return event key value as string
@@ -28,6 +30,8 @@ type StatusItem struct {
 	Msg string
 	// optional status change error
 	Err error
+	// optional path of the file the status change is about
+	Path string
 }
 
 // observable like data structure used to stream status changes
@@ -35,6 +35,28 @@ func ExampleSafelock_Encrypt() {
 	// Output:
 }
 
+func ExampleSafelock_List() {
+	lock := safelock.New()
+	password := "testing123456"
+	ctx := context.Background()
+
+	// Disable logs and output
+	lock.Quiet = true
+
+	// Prepare file to list and clean up after test
+	encryptedFile := getEncryptedFile(password)
+	defer os.Remove(encryptedFile.Name())
+
+	// This will list files within `encryptedFile` without extracting them
+	entries, err := lock.List(ctx, encryptedFile, password)
+
+	if err != nil || len(entries) != 1 {
+		fmt.Println("failed!")
+	}
+
+	// Output:
+}
+
 func ExampleSafelock_Decrypt() {
 	lock := safelock.New()
 	password := "testing123456"
@@ -0,0 +1,217 @@
+package safelock
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/mholt/archiver/v4"
+	"github.com/mrf345/safelock-cli/slErrs"
+)
+
+// extracts archive files into the output directory
+type fileExtractor struct {
+	DecryptionConfig
+	outputPath string
+	dirs       []extractedDir
+	statusObs  *StatusObservable
+	// output paths of files renamed due to conflicts, by their archive names
+	renamed map[string]string
+}
+
+type extractedDir struct {
+	fullPath string
+	file     archiver.File
+}
+
+func newFileExtractor(outputPath string, config DecryptionConfig, statusObs *StatusObservable) *fileExtractor {
+	return &fileExtractor{
+		DecryptionConfig: config,
+		outputPath:       outputPath,
+		statusObs:        statusObs,
+		renamed:          make(map[string]string),
+	}
+}
+
+func (fe *fileExtractor) handleFile(ctx context.Context, file archiver.File) (err error) {
+	var fullPath, outputPath string
+
+	if fullPath, err = getSafeOutputPath(fe.outputPath, file.NameInArchive); err != nil {
+		return
+	}
+
+	if file.IsDir() {
+		// an earlier entry could've placed a link here, which creating the directory would follow
+		if info, statErr := os.Lstat(fullPath); statErr == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return &slErrs.ErrUnsafePath{Path: file.NameInArchive, Reason: "directory is a symbolic link"}
+		}
+
+		if err = os.MkdirAll(fullPath, 0755); err != nil {
+			return fmt.Errorf("failed to create decrypted directory > %w", err)
+		}
+
+		fe.dirs = append(fe.dirs, extractedDir{fullPath: fullPath, file: file})
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create decrypted directory > %w", err)
+	}
+
+	// devices, pipes and other special files are not recreated
+	if !file.Mode().IsRegular() && file.Mode()&fs.ModeSymlink == 0 {
+		return
+	}
+
+	if outputPath, err = fe.resolveConflict(fullPath, file); err != nil || outputPath == "" {
+		return
+	}
+
+	if outputPath != fullPath {
+		fe.renamed[file.NameInArchive] = outputPath
+		fullPath = outputPath
+	}
+
+	header, isTar := file.Header.(*tar.Header)
+
+	switch {
+	case file.Mode()&fs.ModeSymlink != 0:
+		return fe.writeSymlink(fullPath, file)
+	case isTar && header.Typeflag == tar.TypeLink:
+		return fe.writeHardLink(fullPath, header.Linkname)
+	}
+
+	if err = fe.writeFile(fullPath, file); err != nil {
+		return
+	}
+
+	return fe.restoreAttributes(fullPath, file)
+}
+
+func (fe *fileExtractor) writeSymlink(fullPath string, file archiver.File) (err error) {
+	if err = removeFile(fullPath); err != nil {
+		return
+	}
+
+	if err = os.Symlink(file.LinkTarget, fullPath); err != nil {
+		return fmt.Errorf("failed to create symbolic link > %w", err)
+	}
+
+	// links permissions and times can't be changed without changing what they point to
+	if header, isTar := file.Header.(*tar.Header); fe.PreserveOwnership && isTar && os.Geteuid() == 0 {
+		if err = os.Lchown(fullPath, header.Uid, header.Gid); err != nil {
+			return fmt.Errorf("failed to restore ownership > %w", err)
+		}
+	}
+
+	return
+}
+
+// hard links share the attributes of the file they link to, which are already restored
+func (fe *fileExtractor) writeHardLink(fullPath, linkName string) (err error) {
+	var targetPath string
+
+	if targetPath, err = getSafeOutputPath(fe.outputPath, linkName); err != nil {
+		return
+	}
+
+	if renamedPath, ok := fe.renamed[linkName]; ok {
+		targetPath = renamedPath
+	}
+
+	if err = removeFile(fullPath); err != nil {
+		return
+	}
+
+	if err = os.Link(targetPath, fullPath); err != nil {
+		return fmt.Errorf("failed to create hard link > %w", err)
+	}
+
+	return
+}
+
+func (fe *fileExtractor) writeFile(fullPath string, file archiver.File) (err error) {
+	var outputFile *os.File
+	var reader io.ReadCloser
+	var perm os.FileMode = 0666
+
+	// stay private till written, the original permissions are restored afterwards
+	if fe.PreservePermissions {
+		perm = 0600
+	}
+
+	if reader, err = file.Open(); err != nil {
+		return fmt.Errorf("failed to open within archive file > %w", err)
+	}
+	defer reader.Close()
+
+	if err = removeSymlink(fullPath); err != nil {
+		return
+	}
+
+	if outputFile, err = os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm); err != nil {
+		return fmt.Errorf("failed to create decrypted file > %w", err)
+	}
+	defer outputFile.Close()
+
+	if _, err = io.Copy(outputFile, reader); err != nil {
+		return fmt.Errorf("failed to write decrypted file > %w", err)
+	}
+
+	return outputFile.Close()
+}
+
+// restores directories attributes last, otherwise their content would change their times,
+// and restrictive permissions would prevent writing into them
+func (fe *fileExtractor) finish() (err error) {
+	for idx := len(fe.dirs) - 1; idx >= 0; idx-- {
+		if err = fe.restoreAttributes(fe.dirs[idx].fullPath, fe.dirs[idx].file); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (fe *fileExtractor) restoreAttributes(fullPath string, file archiver.File) (err error) {
+	var info fs.FileInfo
+	header, isTar := file.Header.(*tar.Header)
+
+	if info, err = os.Lstat(fullPath); err != nil {
+		return fmt.Errorf("failed to restore attributes > %w", err)
+	}
+
+	// permissions and times of links can't be changed without changing what they point to
+	isLink := info.Mode()&fs.ModeSymlink != 0
+
+	if fe.PreservePermissions && !isLink {
+		if err = os.Chmod(fullPath, file.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to restore permissions > %w", err)
+		}
+	}
+
+	// only root can give files away to other users
+	if fe.PreserveOwnership && isTar && os.Geteuid() == 0 {
+		if err = os.Lchown(fullPath, header.Uid, header.Gid); err != nil {
+			return fmt.Errorf("failed to restore ownership > %w", err)
+		}
+	}
+
+	if fe.PreserveTimes && !isLink {
+		accessTime := file.ModTime()
+
+		if isTar && !header.AccessTime.IsZero() {
+			accessTime = header.AccessTime
+		}
+
+		if err = os.Chtimes(fullPath, accessTime, file.ModTime()); err != nil {
+			return fmt.Errorf("failed to restore times > %w", err)
+		}
+	}
+
+	return
+}
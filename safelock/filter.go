@@ -0,0 +1,91 @@
+package safelock
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// include and exclude glob patterns of archive paths, patterns support `**` to match
+// any number of directories, and the ones with no slash match any path segment (like `*.tmp`)
+type pathFilter struct {
+	include, exclude []string
+}
+
+func (pf pathFilter) validate() (err error) {
+	for _, pattern := range append(append([]string{}, pf.include...), pf.exclude...) {
+		if _, err = path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid glob pattern (%s) > %w", pattern, err)
+		}
+	}
+
+	return
+}
+
+func (pf pathFilter) isEmpty() bool {
+	return len(pf.include) == 0 && len(pf.exclude) == 0
+}
+
+func (pf pathFilter) isExcluded(name string) bool {
+	return matchAnyPattern(pf.exclude, name)
+}
+
+func (pf pathFilter) isIncluded(name string) bool {
+	if pf.isExcluded(name) {
+		return false
+	}
+
+	return len(pf.include) == 0 || matchAnyPattern(pf.include, name)
+}
+
+// checks if `name` or any of its parent directories match any of the patterns
+func matchAnyPattern(patterns []string, name string) bool {
+	name = strings.Trim(path.Clean("/"+name), "/")
+
+	for _, pattern := range patterns {
+		for current := name; current != "." && current != ""; current = path.Dir(current) {
+			if matchPattern(pattern, current) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchPattern(pattern, name string) bool {
+	pattern = strings.Trim(pattern, "/")
+
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for skip := 0; skip <= len(names); skip++ {
+				if matchSegments(patterns[1:], names[skip:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(names) == 0 {
+			return false
+		}
+
+		if matched, _ := path.Match(patterns[0], names[0]); !matched {
+			return false
+		}
+
+		patterns, names = patterns[1:], names[1:]
+	}
+
+	return len(names) == 0
+}
@@ -0,0 +1,258 @@
+package safelock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/mholt/archiver/v4"
+	"github.com/mrf345/safelock-cli/slErrs"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// latest encrypted file format version
+const FormatVersion uint8 = 5
+
+// oldest supported format version, older ones were only created by pre-release builds
+const oldestFormatVersion uint8 = 5
+
+// upper bounds of the key derivation parameters read from files, so a crafted preamble
+// can't exhaust memory or hang decryption before anything is authenticated
+const (
+	maxIterationCount uint32 = 16
+	maxMemSize        uint32 = 1024 * 1024
+	maxThreads        uint8  = 128
+	// iterations times memory size of deriving the keys of every password slot, which
+	// opening a file with a wrong password takes
+	maxKeyDerivationCost uint64 = 8 * 1024 * 1024
+)
+
+// identifies files created by safelock, starts every encrypted file
+var magicBytes = []byte("SLCK")
+
+// format identifier of unknown (custom) compression or archival
+const customFormatId uint8 = 255
+
+var compressionIds = map[string]uint8{
+	"":     0,
+	".zst": 1,
+	".gz":  2,
+	".bz2": 3,
+	".xz":  4,
+	".lz4": 5,
+	".sz":  6,
+	".br":  7,
+	".zz":  8,
+}
+
+var compressions = map[uint8]func() archiver.Compression{
+	1: func() archiver.Compression { return archiver.Zstd{} },
+	2: func() archiver.Compression { return archiver.Gz{} },
+	3: func() archiver.Compression { return archiver.Bz2{} },
+	4: func() archiver.Compression { return archiver.Xz{} },
+	5: func() archiver.Compression { return archiver.Lz4{} },
+	6: func() archiver.Compression { return archiver.Sz{} },
+	7: func() archiver.Compression { return archiver.Brotli{} },
+	8: func() archiver.Compression { return archiver.Zlib{} },
+}
+
+var archivalIds = map[string]uint8{
+	".tar":    1,
+	".zip":    2,
+	".stream": 3,
+}
+
+var archivals = map[uint8]func() archiver.Archival{
+	1: func() archiver.Archival { return archiver.Tar{} },
+	2: func() archiver.Archival { return archiver.Zip{} },
+	3: func() archiver.Archival { return streamArchival{} },
+}
+
+// fixed size part of the file preamble, written right after the magic bytes
+type preambleFields struct {
+	Version        uint8
+	IterationCount uint32
+	MemSize        uint32
+	Threads        uint8
+	KeyLength      uint32
+	SaltLength     uint16
+	HeaderRatio    uint32
+	Compression    uint8
+	Archival       uint8
+}
+
+// the versioned file preamble that describes how the file was encrypted
+type preamble struct {
+	preambleFields
+	// size of the preamble in bytes (zero for legacy files)
+	size int64
+}
+
+func newPreamble(config EncryptionConfig, ac ArchiverConfig) (p preamble) {
+	p = preamble{
+		preambleFields: preambleFields{
+			Version:        FormatVersion,
+			IterationCount: config.IterationCount,
+			MemSize:        config.MemSize,
+			Threads:        config.Threads,
+			KeyLength:      config.KeyLength,
+			SaltLength:     uint16(config.SaltLength),
+			HeaderRatio:    uint32(config.HeaderRatio),
+			Compression:    getFormatId(compressionIds, ac.Compression),
+			Archival:       getFormatId(archivalIds, ac.Archival),
+		},
+	}
+
+	p.size = p.getSize()
+	return
+}
+
+func (p preamble) getSize() int64 {
+	return int64(len(magicBytes) + binary.Size(p.preambleFields))
+}
+
+func getFormatId(ids map[string]uint8, format archiver.Format) uint8 {
+	if format == nil {
+		return ids[""]
+	}
+
+	if id, ok := ids[format.Name()]; ok {
+		return id
+	}
+
+	return customFormatId
+}
+
+// whether the file was created before the format was versioned, so it derives its key from the password
+// and a salt rather than key slots, and lists its chunks in a plain trailing header that requires seeking
+// rather than framing them inline and flagging the final one
+func (p preamble) isLegacy() bool {
+	return p.size == 0
+}
+
+func (p preamble) bytes() []byte {
+	buf := bytes.NewBuffer(append([]byte{}, magicBytes...))
+	_ = binary.Write(buf, binary.BigEndian, p.preambleFields)
+	return buf.Bytes()
+}
+
+// reads the preamble of `r` or rewinds it if it's a legacy file with no preamble
+func readPreamble(r io.Reader) (p preamble, err error) {
+	magic := make([]byte, len(magicBytes))
+	seeker, seekable := asSeeker(r)
+
+	if seekable {
+		if _, err = seeker.Seek(0, io.SeekStart); err != nil {
+			err = fmt.Errorf("failed to read input > %w", err)
+			return
+		}
+	}
+
+	if _, err = io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, magicBytes) {
+		if !seekable {
+			err = &slErrs.ErrFailedToAuthenticate{Msg: "missing file preamble (legacy files need seekable input)"}
+			return
+		}
+
+		_, err = seeker.Seek(0, io.SeekStart)
+		return
+	}
+
+	fields := make([]byte, binary.Size(p.preambleFields))
+	read, _ := io.ReadFull(r, fields)
+
+	// version goes first, so newer formats are detected regardless of their layout
+	if read > 0 && (fields[0] > FormatVersion || fields[0] < oldestFormatVersion) {
+		err = &slErrs.ErrUnsupportedVersion{Version: fields[0], Oldest: oldestFormatVersion, Latest: FormatVersion}
+		return
+	}
+
+	if read < len(fields) {
+		err = &slErrs.ErrFailedToAuthenticate{Msg: "incomplete file preamble"}
+		return
+	}
+
+	_ = binary.Read(bytes.NewReader(fields), binary.BigEndian, &p.preambleFields)
+
+	if p.SaltLength == 0 || p.HeaderRatio == 0 {
+		err = &slErrs.ErrFailedToAuthenticate{Msg: "invalid file preamble parameters"}
+		return
+	}
+
+	if kdfErr := validateKeyDerivation(p.IterationCount, p.MemSize, p.Threads, p.KeyLength); kdfErr != nil {
+		err = &slErrs.ErrFailedToAuthenticate{Msg: fmt.Sprintf("invalid file preamble parameters (%s)", kdfErr)}
+		return
+	}
+
+	if p.SaltLength > maxSaltLength {
+		err = &slErrs.ErrFailedToAuthenticate{Msg: "invalid file preamble salt length"}
+		return
+	}
+
+	p.size = p.getSize()
+	return
+}
+
+// checks the key derivation parameters are within the bounds files can be decrypted with
+func validateKeyDerivation(iterationCount, memSize uint32, threads uint8, keyLength uint32) error {
+	switch {
+	case iterationCount == 0 || iterationCount > maxIterationCount:
+		return fmt.Errorf("iteration count (%d) must be between 1 and %d", iterationCount, maxIterationCount)
+	case memSize == 0 || memSize > maxMemSize:
+		return fmt.Errorf("memory size (%d) must be between 1 and %d", memSize, maxMemSize)
+	case threads == 0 || threads > maxThreads:
+		return fmt.Errorf("threads (%d) must be between 1 and %d", threads, maxThreads)
+	case keyLength != chacha20poly1305.KeySize:
+		return fmt.Errorf("key length (%d) must be %d", keyLength, chacha20poly1305.KeySize)
+	case uint64(iterationCount)*uint64(memSize)*keySlotsCount > maxKeyDerivationCost:
+		return fmt.Errorf(
+			"iteration count (%d) times memory size (%d) exceeds the maximum (%d)",
+			iterationCount, memSize, maxKeyDerivationCost/keySlotsCount,
+		)
+	}
+
+	return nil
+}
+
+func (p preamble) configureEncryption(config *EncryptionConfig) {
+	config.IterationCount = p.IterationCount
+	config.MemSize = p.MemSize
+	config.Threads = p.Threads
+	config.KeyLength = p.KeyLength
+	config.SaltLength = int(p.SaltLength)
+	config.HeaderRatio = int(p.HeaderRatio)
+}
+
+// returns a copy of `sl` configured with the preamble settings
+func (p preamble) configure(sl Safelock) (configured Safelock, err error) {
+	configured = sl
+
+	if p.isLegacy() {
+		return
+	}
+
+	p.configureEncryption(&configured.EncryptionConfig)
+
+	if p.Compression != getFormatId(compressionIds, sl.Compression) {
+		if p.Compression == 0 {
+			configured.Compression = nil
+		} else if newCompression, ok := compressions[p.Compression]; ok {
+			configured.Compression = newCompression()
+		} else {
+			err = fmt.Errorf("unsupported compression format (%d)", p.Compression)
+			return
+		}
+	}
+
+	if p.Archival != getFormatId(archivalIds, sl.Archival) {
+		if newArchival, ok := archivals[p.Archival]; ok {
+			configured.Archival = newArchival()
+		} else {
+			err = fmt.Errorf("unsupported archival format (%d)", p.Archival)
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,143 @@
+package safelock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mrf345/safelock-cli/slErrs"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// the header written after the chunks, encrypted with a key derived from the file key
+type fileHeader struct {
+	// sizes of the encrypted chunks separated by `;`
+	Blocks   string   `json:"blocks"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// biggest header read after the chunks, whatever their size
+const maxHeaderSize = 1024 * 1024 * 1024
+
+// biggest encoded metadata stored in the header
+const maxMetadataSize = 32 * 1024
+
+// room for the header encoding, its nonce, tag and MAC, along with the metadata
+const headerOverhead = maxMetadataSize + 1024
+
+// biggest encoded chunk size within the header, including its separator
+const maxBlockSize = 11
+
+// size the header of `framesSize` bytes of chunk frames is padded to, so its size reveals little of the content
+func getPaddedHeaderSize(framesSize, ratio int) int {
+	size := framesSize / ratio
+
+	if ratio > size {
+		return ratio
+	}
+
+	return (framesSize + size) / ratio
+}
+
+// biggest header that can follow `chunks` chunk frames of `framesSize` bytes
+func getMaxHeaderSize(framesSize, chunks, ratio int) int {
+	return min(maxHeaderSize, getPaddedHeaderSize(framesSize, ratio)+chunks*maxBlockSize+headerOverhead)
+}
+
+func getHeaderKey(fileKey []byte) []byte {
+	key := make([]byte, sha256.Size)
+	_, _ = io.ReadFull(hkdf.New(sha256.New, fileKey, nil, headerKeyInfo), key)
+	return key
+}
+
+// encrypts `header` padded to at least `size` bytes, authenticating the preamble along with it
+func sealHeader(key, preamble []byte, header fileHeader, size int) (sealed []byte, err error) {
+	var encoded []byte
+
+	if encoded, err = json.Marshal(header); err != nil {
+		return nil, fmt.Errorf("failed to encode header > %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AEAD > %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce > %w", err)
+	}
+
+	padded := append(encoded, make([]byte, max(0, size-len(encoded)))...)
+	return aead.Seal(nonce, nonce, padded, preamble), nil
+}
+
+func openHeader(key, preamble, sealed []byte) (header fileHeader, err error) {
+	var padded []byte
+	aead, err := chacha20poly1305.NewX(key)
+
+	if err != nil {
+		return header, fmt.Errorf("failed to create AEAD > %w", err)
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return header, &slErrs.ErrFailedToAuthenticate{Msg: "missing header"}
+	}
+
+	nonce, encrypted := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	if padded, err = aead.Open(nil, nonce, encrypted, preamble); err != nil {
+		return header, &slErrs.ErrFailedToAuthenticate{Msg: "header or preamble was changed"}
+	}
+
+	if err = json.Unmarshal(bytes.TrimRight(padded, "\x00"), &header); err != nil {
+		return header, &slErrs.ErrFailedToAuthenticate{Msg: "invalid header content"}
+	}
+
+	return
+}
+
+// walks the size prefixed chunks frames of `r` without reading their content,
+// and returns their sizes along with the header that follows them, padded based on `ratio`
+func scanFrames(r io.ReadSeeker, ratio int) (sizes []int, header []byte, err error) {
+	var framesSize int
+	sizeBytes := make([]byte, frameSizeLength)
+
+	for {
+		if _, err = io.ReadFull(r, sizeBytes); err != nil {
+			return nil, nil, &slErrs.ErrTruncatedInput{Chunk: len(sizes)}
+		}
+
+		size := binary.BigEndian.Uint32(sizeBytes)
+
+		if size == 0 {
+			break
+		}
+
+		if _, err = r.Seek(int64(size), io.SeekCurrent); err != nil {
+			return nil, nil, fmt.Errorf("failed to read input > %w", err)
+		}
+
+		sizes = append(sizes, int(size))
+		framesSize += frameSizeLength + int(size)
+	}
+
+	limit := getMaxHeaderSize(framesSize, len(sizes), ratio)
+
+	if header, err = io.ReadAll(io.LimitReader(r, int64(limit)+1)); err != nil {
+		return nil, nil, fmt.Errorf("failed to read input header > %w", err)
+	}
+
+	if len(header) > limit {
+		return nil, nil, &slErrs.ErrFailedToAuthenticate{Msg: "header is bigger than the encrypted content allows"}
+	}
+
+	return
+}
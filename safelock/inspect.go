@@ -0,0 +1,175 @@
+package safelock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mholt/archiver/v4"
+)
+
+// how an encrypted file was encrypted, and what it's made of
+type Inspection struct {
+	// encrypted file format version, zero for files created before versioning
+	Version uint8 `json:"version"`
+	// content encryption cipher
+	Cipher string `json:"cipher"`
+	// password key derivation function and its parameters
+	KDF            string `json:"kdf"`
+	IterationCount uint32 `json:"iterationCount"`
+	MemSize        uint32 `json:"memSize"`
+	Threads        uint8  `json:"threads"`
+	KeyLength      uint32 `json:"keyLength"`
+	SaltLength     int    `json:"saltLength"`
+	// content compression and archival formats
+	Compression string `json:"compression"`
+	Archival    string `json:"archival"`
+	// used key slots, empty for files created before versioning
+	KeySlots []KeySlotInfo `json:"keySlots,omitempty"`
+	// number of encrypted chunks, and their total size in bytes
+	Chunks      int   `json:"chunks"`
+	PayloadSize int64 `json:"payloadSize"`
+	// size of the header that follows the chunks in bytes
+	HeaderSize int64 `json:"headerSize"`
+	// decrypted content summary, only set by [safelock.Safelock.InspectWithPassword]
+	Content *VerifyReport `json:"content,omitempty"`
+}
+
+// inspects `input` which must be an object that implements [io.ReadSeeker] such as [os.File], without a
+// password, files created before versioning are assumed to be encrypted with the current settings
+func (sl *Safelock) Inspect(input io.ReadSeeker) (inspection Inspection, err error) {
+	var pre preamble
+	var fileSl Safelock
+	var sizes []int
+
+	if pre, err = readPreamble(input); err != nil {
+		err = fmt.Errorf("failed to read input preamble > %w", err)
+		return
+	}
+
+	if fileSl, err = pre.configure(*sl); err != nil {
+		err = fmt.Errorf("invalid input preamble > %w", err)
+		return
+	}
+
+	inspection = Inspection{
+		Version:        pre.Version,
+		Cipher:         "XChaCha20-Poly1305",
+		KDF:            "Argon2id",
+		IterationCount: fileSl.IterationCount,
+		MemSize:        fileSl.MemSize,
+		Threads:        fileSl.Threads,
+		KeyLength:      fileSl.KeyLength,
+		SaltLength:     fileSl.SaltLength,
+		Compression:    getFormatName(fileSl.Compression),
+		Archival:       getFormatName(fileSl.Archival),
+	}
+
+	if !pre.isLegacy() {
+		var slots keySlots
+
+		if slots, err = readKeySlots(input); err != nil {
+			return
+		}
+
+		inspection.KeySlots = slots.infos()
+	} else if _, err = input.Seek(pre.size+int64(fileSl.SaltLength), io.SeekStart); err != nil {
+		err = fmt.Errorf("failed to read input > %w", err)
+		return
+	}
+
+	if !pre.isLegacy() {
+		var header []byte
+
+		if sizes, header, err = scanFrames(input, fileSl.HeaderRatio); err != nil {
+			return
+		}
+
+		inspection.HeaderSize = int64(len(header))
+	} else if sizes, inspection.HeaderSize, err = readLegacyBlocks(input, pre, fileSl.HeaderRatio); err != nil {
+		return
+	}
+
+	inspection.Chunks = len(sizes)
+
+	for _, size := range sizes {
+		inspection.PayloadSize += int64(size)
+	}
+
+	return
+}
+
+// inspects `input` like [safelock.Safelock.Inspect], and also decrypts and verifies its content with
+// `password` or [safelock.EncryptionConfig.Identities] without writing it
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) InspectWithPassword(
+	ctx context.Context,
+	input io.ReadSeeker,
+	password string,
+) (inspection Inspection, err error) {
+	var report VerifyReport
+
+	if inspection, err = sl.Inspect(input); err != nil {
+		return
+	}
+
+	if _, err = input.Seek(0, io.SeekStart); err != nil {
+		err = fmt.Errorf("failed to read input > %w", err)
+		return
+	}
+
+	if report, err = sl.Verify(ctx, input, password); err != nil {
+		return
+	}
+
+	inspection.Content = &report
+	return
+}
+
+// reads the plain header listing the chunk sizes of legacy files
+func readLegacyBlocks(input io.ReadSeeker, pre preamble, headerRatio int) (sizes []int, headerSize int64, err error) {
+	var end int64
+
+	if end, err = input.Seek(0, io.SeekEnd); err != nil {
+		err = fmt.Errorf("failed to read input > %w", err)
+		return
+	}
+
+	headerSize = int64(getHeaderSize(int(end-pre.size), headerRatio))
+	header := make([]byte, headerSize)
+
+	if _, err = input.Seek(end-headerSize, io.SeekStart); err != nil {
+		err = fmt.Errorf("can't seek header > %w", err)
+		return
+	}
+
+	if _, err = io.ReadFull(input, header); err != nil {
+		err = fmt.Errorf("can't read header > %w", err)
+		return
+	}
+
+	for _, block := range parseHeader(header) {
+		var size int
+
+		if size, err = strconv.Atoi(block); err != nil {
+			err = fmt.Errorf("invalid header block size > %w", err)
+			return
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return
+}
+
+// name of the compression or archival format, such as zst or tar
+func getFormatName(format archiver.Format) string {
+	if format == nil {
+		return "none"
+	}
+
+	return strings.TrimPrefix(format.Name(), ".")
+}
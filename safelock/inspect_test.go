@@ -0,0 +1,50 @@
+package safelock_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	content := "Hello World!"
+	sl := GetQuietGzipSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+
+	_, _ = inputFile.WriteString(content)
+	inputFile.Close()
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	inspection, inspectErr := GetQuietSafelock().Inspect(outputFile)
+	unlocked, unlockedErr := GetQuietSafelock().InspectWithPassword(context.TODO(), outputFile, password)
+	_, wrongErr := GetQuietSafelock().InspectWithPassword(context.TODO(), outputFile, "wrong password")
+	info, _ := outputFile.Stat()
+
+	assert.Nil(encErr)
+	assert.Nil(inspectErr)
+	assert.Equal(safelock.FormatVersion, inspection.Version)
+	assert.Equal(sl.IterationCount, inspection.IterationCount)
+	assert.Equal(sl.SaltLength, inspection.SaltLength)
+	assert.Equal("gz", inspection.Compression)
+	assert.Equal("tar", inspection.Archival)
+	assert.Equal([]safelock.KeySlotInfo{{Index: 0, Type: "password"}}, inspection.KeySlots)
+	assert.Greater(inspection.Chunks, 0)
+	assert.Less(inspection.PayloadSize+inspection.HeaderSize, info.Size())
+	assert.Nil(inspection.Content)
+
+	assert.Nil(unlockedErr)
+	assert.Equal(inspection.Chunks, unlocked.Content.Chunks)
+	assert.Equal(1, unlocked.Content.Entries)
+	assert.Equal(int64(len(content)), unlocked.Content.Size)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](wrongErr))
+}
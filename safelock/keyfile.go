@@ -0,0 +1,75 @@
+package safelock
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// size of keyfiles created by [safelock.GenerateKeyfile]
+const keyfileSize = 64
+
+// writes a new high-entropy keyfile into `output`
+func GenerateKeyfile(output io.Writer) (err error) {
+	if _, err = io.CopyN(output, rand.Reader, keyfileSize); err != nil {
+		err = fmt.Errorf("failed to generate keyfile > %w", err)
+	}
+
+	return
+}
+
+// uses `keyfile` content which can be any file as key material along with the password, or instead
+// of it if the password is empty, so the same keyfile is needed to decrypt what's encrypted with it
+func (ec *EncryptionConfig) SetKeyfile(keyfile io.Reader) (err error) {
+	ec.keyfileHash, err = hashKeyfile(keyfile)
+	return
+}
+
+// uses `keyfile` content along with the new password of key slots added by [safelock.Safelock.AddPasswordSlot]
+// and [safelock.Safelock.Rekey], or instead of it if the new password is empty, in place of the keyfile set with
+// [safelock.EncryptionConfig.SetKeyfile] which they keep otherwise
+func (ec *EncryptionConfig) SetNewKeyfile(keyfile io.Reader) (err error) {
+	ec.newKeyfileHash, err = hashKeyfile(keyfile)
+	return
+}
+
+func hashKeyfile(keyfile io.Reader) (digest []byte, err error) {
+	hash := sha256.New()
+
+	if _, err = io.Copy(hash, keyfile); err != nil {
+		return nil, fmt.Errorf("failed to read keyfile > %w", err)
+	}
+
+	return hash.Sum(nil), nil
+}
+
+func (ec EncryptionConfig) hasKeyfile() bool {
+	return len(ec.keyfileHash) > 0
+}
+
+func (ec EncryptionConfig) hasNewKeyfile() bool {
+	return len(ec.getNewKeyfileHash()) > 0
+}
+
+// keyfile of added key slots, the new one if set, otherwise the one that unlocks the file unless dropped
+func (ec EncryptionConfig) getNewKeyfileHash() []byte {
+	if len(ec.newKeyfileHash) > 0 {
+		return ec.newKeyfileHash
+	}
+
+	if ec.DropKeyfile {
+		return nil
+	}
+
+	return ec.keyfileHash
+}
+
+// password and keyfile key material, or nil if neither is set
+func (ec EncryptionConfig) getSecret(pwd string) (secret []byte) {
+	if pwd == "" && !ec.hasKeyfile() {
+		return
+	}
+
+	return append([]byte(pwd), ec.keyfileHash...)
+}
@@ -0,0 +1,82 @@
+package safelock_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKeyfile(t *testing.T) {
+	assert := assert.New(t)
+	first, second := new(bytes.Buffer), new(bytes.Buffer)
+
+	assert.Nil(safelock.GenerateKeyfile(first))
+	assert.Nil(safelock.GenerateKeyfile(second))
+	assert.Equal(64, first.Len())
+	assert.NotEqual(first.Bytes(), second.Bytes())
+}
+
+func TestEncryptWithKeyfile(t *testing.T) {
+	assert := assert.New(t)
+	keyfile, otherKeyfile := new(bytes.Buffer), new(bytes.Buffer)
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	wrongSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	_ = safelock.GenerateKeyfile(keyfile)
+	_ = safelock.GenerateKeyfile(otherKeyfile)
+	_ = encSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+	_ = decSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+	_ = wrongSl.SetKeyfile(otherKeyfile)
+
+	encErr := encSl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, "")
+	wrongErr := wrongSl.Decrypt(context.TODO(), outputFile, outputDir, "")
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, "")
+
+	assert.Nil(encErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](wrongErr))
+	assert.Nil(decErr)
+}
+
+func TestEncryptWithPasswordAndKeyfile(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	keyfile := new(bytes.Buffer)
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	_ = safelock.GenerateKeyfile(keyfile)
+	_ = encSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+
+	encErr := encSl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	passwordOnlyErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	_ = decSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+	keyfileOnlyErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, "")
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+
+	assert.Nil(encErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](passwordOnlyErr))
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](keyfileOnlyErr))
+	assert.Nil(decErr)
+}
@@ -0,0 +1,132 @@
+package safelock
+
+import (
+	"bufio"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// prefixes of encoded keys
+const (
+	recipientPrefix = "slpub1"
+	identityPrefix  = "SLSEC1"
+)
+
+// X25519 public key files can be encrypted to, safe to share with anyone
+type Recipient struct {
+	key *ecdh.PublicKey
+}
+
+// X25519 private key that decrypts files encrypted to its [safelock.Recipient], must be kept secret
+type Identity struct {
+	key *ecdh.PrivateKey
+}
+
+// generates a new random [safelock.Identity]
+func GenerateIdentity() (identity *Identity, err error) {
+	var key *ecdh.PrivateKey
+
+	if key, err = ecdh.X25519().GenerateKey(rand.Reader); err != nil {
+		err = fmt.Errorf("failed to generate identity > %w", err)
+		return
+	}
+
+	return &Identity{key: key}, nil
+}
+
+// parses a [safelock.Recipient] encoded with [safelock.Recipient.String]
+func ParseRecipient(encoded string) (recipient *Recipient, err error) {
+	var key *ecdh.PublicKey
+	var raw []byte
+
+	if raw, err = decodeKey(encoded, recipientPrefix); err != nil {
+		return
+	}
+
+	if key, err = ecdh.X25519().NewPublicKey(raw); err != nil {
+		err = fmt.Errorf("invalid recipient > %w", err)
+		return
+	}
+
+	return &Recipient{key: key}, nil
+}
+
+// parses a [safelock.Identity] encoded with [safelock.Identity.String]
+func ParseIdentity(encoded string) (identity *Identity, err error) {
+	var key *ecdh.PrivateKey
+	var raw []byte
+
+	if raw, err = decodeKey(encoded, identityPrefix); err != nil {
+		return
+	}
+
+	if key, err = ecdh.X25519().NewPrivateKey(raw); err != nil {
+		err = fmt.Errorf("invalid identity > %w", err)
+		return
+	}
+
+	return &Identity{key: key}, nil
+}
+
+// reads identities from `r` such as an identity file, one per line, skipping empty lines and `#` comments
+func ReadIdentities(r io.Reader) (identities []*Identity, err error) {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		var identity *Identity
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if identity, err = ParseIdentity(line); err != nil {
+			return
+		}
+
+		identities = append(identities, identity)
+	}
+
+	if err = scanner.Err(); err != nil {
+		err = fmt.Errorf("failed to read identities > %w", err)
+		return
+	}
+
+	if len(identities) == 0 {
+		err = fmt.Errorf("no identities found")
+	}
+
+	return
+}
+
+// public key of the identity to encrypt files to
+func (id *Identity) Recipient() *Recipient {
+	return &Recipient{key: id.key.PublicKey()}
+}
+
+func (id *Identity) String() string {
+	return identityPrefix + base64.RawURLEncoding.EncodeToString(id.key.Bytes())
+}
+
+func (r *Recipient) String() string {
+	return recipientPrefix + base64.RawURLEncoding.EncodeToString(r.key.Bytes())
+}
+
+func decodeKey(encoded, prefix string) (raw []byte, err error) {
+	encoded = strings.TrimSpace(encoded)
+
+	if !strings.HasPrefix(encoded, prefix) {
+		err = fmt.Errorf("invalid key, expected it to start with (%s)", prefix)
+		return
+	}
+
+	if raw, err = base64.RawURLEncoding.DecodeString(encoded[len(prefix):]); err != nil {
+		err = fmt.Errorf("invalid key encoding > %w", err)
+	}
+
+	return
+}
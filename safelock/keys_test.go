@@ -0,0 +1,100 @@
+package safelock_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	assert := assert.New(t)
+	identity, genErr := safelock.GenerateIdentity()
+	parsedIdentity, identityErr := safelock.ParseIdentity(identity.String())
+	parsedRecipient, recipientErr := safelock.ParseRecipient(identity.Recipient().String())
+	_, invalidErr := safelock.ParseRecipient(identity.String())
+
+	assert.Nil(genErr)
+	assert.Nil(identityErr)
+	assert.Nil(recipientErr)
+	assert.NotNil(invalidErr)
+	assert.Equal(identity.String(), parsedIdentity.String())
+	assert.Equal(identity.Recipient().String(), parsedRecipient.String())
+}
+
+func TestReadIdentities(t *testing.T) {
+	assert := assert.New(t)
+	identity, _ := safelock.GenerateIdentity()
+	content := "# public key: " + identity.Recipient().String() + "\n\n" + identity.String() + "\n"
+
+	identities, err := safelock.ReadIdentities(strings.NewReader(content))
+	_, emptyErr := safelock.ReadIdentities(strings.NewReader("# nothing here\n"))
+
+	assert.Nil(err)
+	assert.NotNil(emptyErr)
+	assert.Len(identities, 1)
+	assert.Equal(identity.String(), identities[0].String())
+}
+
+func TestEncryptToRecipients(t *testing.T) {
+	assert := assert.New(t)
+	content := "Hello World!"
+	alice, _ := safelock.GenerateIdentity()
+	bob, _ := safelock.GenerateIdentity()
+	eve, _ := safelock.GenerateIdentity()
+	encSl := GetQuietSafelock()
+	bobSl := GetQuietSafelock()
+	eveSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	decryptedPath := filepath.Join(outputDir, filepath.Base(inputFile.Name()))
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	_, _ = inputFile.WriteString(content)
+	inputFile.Close()
+
+	encSl.Recipients = []*safelock.Recipient{alice.Recipient(), bob.Recipient()}
+	bobSl.Identities = []*safelock.Identity{bob}
+	eveSl.Identities = []*safelock.Identity{eve}
+
+	encErr := encSl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, "")
+	eveErr := eveSl.Decrypt(context.TODO(), outputFile, outputDir, "")
+	bobErr := bobSl.Decrypt(context.TODO(), outputFile, outputDir, "")
+	decrypted, _ := os.ReadFile(decryptedPath)
+
+	assert.Nil(encErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](eveErr))
+	assert.Nil(bobErr)
+	assert.Equal(content, string(decrypted))
+}
+
+func TestEncryptToRecipientsAndPassword(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	identity, _ := safelock.GenerateIdentity()
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	encSl.Recipients = []*safelock.Recipient{identity.Recipient()}
+	encErr := encSl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+
+	assert.Nil(encErr)
+	assert.Nil(decErr)
+}
@@ -0,0 +1,71 @@
+package safelock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/mholt/archiver/v4"
+)
+
+// details of a file within an encrypted file
+type ArchiveEntry struct {
+	// path of the file within the archive
+	Name string `json:"name"`
+	// size of the file content in bytes
+	Size int64 `json:"size"`
+	// file mode and permission bits
+	Mode fs.FileMode `json:"mode"`
+	// last modification time
+	ModTime time.Time `json:"modTime"`
+}
+
+// lists the files within `input` which must be an object that implements [io.Reader] such as [os.File],
+// without extracting them
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) List(ctx context.Context, input io.Reader, password string) (entries []ArchiveEntry, err error) {
+	err = sl.extract(ctx, input, password, extraction{
+		act:  "Listing",
+		done: "All set and listed!",
+		handleFile: func(ctx context.Context, file archiver.File) (err error) {
+			var entry ArchiveEntry
+
+			if entry, err = newArchiveEntry(file); err == nil {
+				entries = append(entries, entry)
+			}
+
+			return
+		},
+	})
+
+	return
+}
+
+func newArchiveEntry(file archiver.File) (entry ArchiveEntry, err error) {
+	var reader io.ReadCloser
+
+	entry = ArchiveEntry{
+		Name:    file.NameInArchive,
+		Size:    file.Size(),
+		Mode:    file.Mode(),
+		ModTime: file.ModTime(),
+	}
+
+	// streams size is only known once read
+	if _, ok := file.FileInfo.(streamFileInfo); ok {
+		if reader, err = file.Open(); err != nil {
+			err = fmt.Errorf("failed to open within archive file > %w", err)
+			return
+		}
+		defer reader.Close()
+
+		if entry.Size, err = io.Copy(io.Discard, reader); err != nil {
+			err = fmt.Errorf("failed to read within archive file > %w", err)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,57 @@
+package safelock_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFiles(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	content := "Hello World!"
+	sl := GetQuietSafelock()
+	inputDir, _ := os.MkdirTemp("", "input_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	inputPaths := []string{inputDir}
+	inputName := filepath.Base(inputDir)
+
+	defer os.RemoveAll(inputDir)
+	defer os.Remove(outputFile.Name())
+	_ = os.WriteFile(filepath.Join(inputDir, "file.txt"), []byte(content), 0600)
+
+	encErr := sl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	entries, listErr := sl.List(context.TODO(), outputFile, password)
+
+	assert.Nil(encErr)
+	assert.Nil(listErr)
+	assert.Len(entries, 2)
+	assert.Equal(inputName, entries[0].Name)
+	assert.True(entries[0].Mode.IsDir())
+	assert.Equal(inputName+"/file.txt", entries[1].Name)
+	assert.Equal(int64(len(content)), entries[1].Size)
+	assert.Equal(os.FileMode(0600), entries[1].Mode.Perm())
+}
+
+func TestListFilesWithWrongPassword(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	inputPaths := []string{inputFile.Name()}
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+
+	encErr := sl.Encrypt(context.TODO(), inputPaths, outputFile, password)
+	entries, listErr := sl.List(context.TODO(), outputFile, "wrong_password")
+
+	assert.Nil(encErr)
+	assert.Nil(entries)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](listErr))
+}
@@ -1,7 +1,8 @@
 // Fast files encryption package ⚡
 //
-// Check [encryption] and [decryption] for interactive examples.
+// Check [encryption], [decryption] and [listing] for interactive examples.
 //
 // [encryption]: https://pkg.go.dev/github.com/mrf345/safelock-cli/safelock#example-Safelock.Encrypt
 // [decryption]: https://pkg.go.dev/github.com/mrf345/safelock-cli/safelock#example-Safelock.Decrypt
+// [listing]: https://pkg.go.dev/github.com/mrf345/safelock-cli/safelock#example-Safelock.List
 package safelock
@@ -0,0 +1,50 @@
+package safelock
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// optional details stored in the encrypted header of encrypted files
+type Metadata struct {
+	// when the file was encrypted
+	CreatedAt time.Time `json:"createdAt"`
+	// name and version of the tool that encrypted the file
+	ToolVersion string `json:"toolVersion,omitempty"`
+	// name of the host the file was encrypted on
+	Hostname string `json:"hostname,omitempty"`
+	// note left by whoever encrypted the file
+	Comment string `json:"comment,omitempty"`
+}
+
+// reads the metadata stored in the encrypted header of `input` which must be an object that implements
+// [io.ReaderAt] such as [os.File], without decrypting its content
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) ReadMetadata(ctx context.Context, input io.ReaderAt, password string) (metadata Metadata, err error) {
+	var unlocked unlockedSlots
+	var header fileHeader
+	var sealed []byte
+
+	if unlocked, err = sl.unlockFileKeySlots(ctx, input, password); err != nil {
+		return
+	}
+
+	contentOffset := unlocked.pre.size + int64(binary.Size(unlocked.keySlots))
+
+	if _, sealed, err = scanFrames(
+		io.NewSectionReader(input, contentOffset, math.MaxInt64),
+		unlocked.config.HeaderRatio,
+	); err != nil {
+		return
+	}
+
+	if header, err = openHeader(getHeaderKey(unlocked.fileKey), unlocked.pre.bytes(), sealed); err != nil {
+		return
+	}
+
+	return header.Metadata, nil
+}
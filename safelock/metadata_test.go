@@ -0,0 +1,54 @@
+package safelock_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMetadata(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	metadata := safelock.Metadata{
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		ToolVersion: "safelock-cli test",
+		Hostname:    "backups",
+		Comment:     "nightly backup",
+	}
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+
+	_, _ = inputFile.WriteString("Hello World!")
+	inputFile.Close()
+	sl.Metadata = &metadata
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	read, readErr := sl.ReadMetadata(context.TODO(), outputFile, password)
+	_, wrongErr := sl.ReadMetadata(context.TODO(), outputFile, "wrong password")
+	report, verifyErr := sl.Verify(context.TODO(), outputFile, password)
+
+	assert.Nil(encErr)
+	assert.Nil(readErr)
+	assert.Nil(verifyErr)
+	assert.Equal(metadata, read)
+	assert.Equal(metadata, report.Metadata)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](wrongErr))
+
+	// change the last byte of the encrypted header
+	info, _ := outputFile.Stat()
+	last := make([]byte, 1)
+	_, _ = outputFile.ReadAt(last, info.Size()-1)
+	_, _ = outputFile.WriteAt([]byte{last[0] ^ 1}, info.Size()-1)
+	_, changedErr := sl.ReadMetadata(context.TODO(), outputFile, password)
+
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](changedErr))
+}
@@ -0,0 +1,66 @@
+package safelock
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrf345/safelock-cli/slErrs"
+)
+
+// joins archive path `name` to `outputPath`, making sure it can't be written outside of it
+// neither directly (absolute or `..` paths) nor through existing symbolic links
+func getSafeOutputPath(outputPath, name string) (fullPath string, err error) {
+	localName := filepath.Clean(filepath.FromSlash(name))
+
+	if !filepath.IsLocal(localName) {
+		err = &slErrs.ErrUnsafePath{Path: name, Reason: "path is outside of the output directory"}
+		return
+	}
+
+	parents := strings.Split(localName, string(filepath.Separator))
+	current := outputPath
+
+	for _, parent := range parents[:len(parents)-1] {
+		var info fs.FileInfo
+		current = filepath.Join(current, parent)
+
+		// missing parents are created as directories later
+		if info, err = os.Lstat(current); err != nil {
+			err = nil
+			break
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			err = &slErrs.ErrUnsafePath{Path: name, Reason: "parent directory is a symbolic link"}
+			return
+		}
+	}
+
+	fullPath = filepath.Join(outputPath, localName)
+	return
+}
+
+// removes `fullPath` if it's a symbolic link, so it gets replaced rather than written through
+func removeSymlink(fullPath string) (err error) {
+	if info, statErr := os.Lstat(fullPath); statErr == nil && info.Mode()&fs.ModeSymlink != 0 {
+		if err = os.Remove(fullPath); err != nil {
+			err = fmt.Errorf("failed to replace symbolic link > %w", err)
+		}
+	}
+
+	return
+}
+
+// removes `fullPath` if it exists and isn't a directory, so a link can be created in its place
+func removeFile(fullPath string) (err error) {
+	if info, statErr := os.Lstat(fullPath); statErr == nil && !info.IsDir() {
+		if err = os.Remove(fullPath); err != nil {
+			err = fmt.Errorf("failed to replace existing file > %w", err)
+		}
+	}
+
+	return
+}
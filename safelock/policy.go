@@ -0,0 +1,129 @@
+package safelock
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/mrf345/safelock-cli/slErrs"
+)
+
+// checks the passwords of newly encrypted files and key slots, should return [slErrs.ErrWeakPassword]
+// explaining why a password is rejected
+type PasswordPolicy interface {
+	Check(password string) error
+}
+
+// rejects passwords by their estimated entropy, number of character classes
+// (lowercase, uppercase, digits, symbols and others) and if they're commonly used
+type StrengthPolicy struct {
+	// minimum estimated entropy in bits
+	MinEntropy float64
+	// minimum number of character classes used
+	MinClasses int
+	// reject passwords found in the embedded list of commonly used ones
+	RejectCommon bool
+}
+
+var (
+	// rejects common and very short passwords
+	BasicPasswordPolicy = StrengthPolicy{MinEntropy: 36, RejectCommon: true}
+	// rejects common passwords, and ones with less than 60 bits of entropy or 3 character classes
+	StrongPasswordPolicy = StrengthPolicy{MinEntropy: 60, MinClasses: 3, RejectCommon: true}
+)
+
+//go:embed commonPasswords.txt
+var commonPasswordsList string
+
+var commonPasswords = func() map[string]bool {
+	passwords := make(map[string]bool)
+
+	for _, password := range strings.Fields(commonPasswordsList) {
+		passwords[password] = true
+	}
+
+	return passwords
+}()
+
+// character classes and the number of characters each has
+var charClasses = []struct {
+	is   func(rune) bool
+	size float64
+}{
+	{unicode.IsLower, 26},
+	{unicode.IsUpper, 26},
+	{unicode.IsDigit, 10},
+	{func(r rune) bool { return r < unicode.MaxASCII && !unicode.IsLetter(r) && !unicode.IsDigit(r) }, 33},
+	{func(r rune) bool { return r > unicode.MaxASCII && !unicode.IsLower(r) && !unicode.IsUpper(r) }, 100},
+}
+
+func (sp StrengthPolicy) Check(password string) error {
+	if sp.RejectCommon && commonPasswords[strings.ToLower(password)] {
+		return &slErrs.ErrWeakPassword{Reason: "password is commonly used"}
+	}
+
+	if classes := countCharClasses(password); classes < sp.MinClasses {
+		return &slErrs.ErrWeakPassword{Reason: fmt.Sprintf(
+			"password uses (%d) character classes expected (%d) of lowercase, uppercase, digits and symbols",
+			classes,
+			sp.MinClasses,
+		)}
+	}
+
+	if entropy := EstimateEntropy(password); entropy < sp.MinEntropy {
+		return &slErrs.ErrWeakPassword{Reason: fmt.Sprintf(
+			"password entropy (%.0f bits) expected (%.0f bits), use a longer password",
+			entropy,
+			sp.MinEntropy,
+		)}
+	}
+
+	return nil
+}
+
+// estimates the entropy of `password` in bits by its unique characters and the sizes of the character classes
+// it uses, which is a rough upper bound for human chosen passwords
+func EstimateEntropy(password string) float64 {
+	var poolSize float64
+	unique := make(map[rune]bool)
+
+	for _, class := range charClasses {
+		for _, char := range password {
+			if class.is(char) {
+				poolSize += class.size
+				break
+			}
+		}
+	}
+
+	for _, char := range password {
+		unique[char] = true
+	}
+
+	if poolSize == 0 {
+		return 0
+	}
+
+	return float64(len(unique)) * math.Log2(poolSize)
+}
+
+func countCharClasses(password string) (count int) {
+	for _, class := range charClasses[:4] {
+		if strings.ContainsFunc(password, class.is) {
+			count += 1
+		}
+	}
+
+	return
+}
+
+// checks `pwd` against the password policy if set, empty passwords are left to the length checks
+func (ec EncryptionConfig) checkPasswordPolicy(pwd string) error {
+	if ec.PasswordPolicy == nil || pwd == "" {
+		return nil
+	}
+
+	return ec.PasswordPolicy.Check(pwd)
+}
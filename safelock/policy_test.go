@@ -0,0 +1,56 @@
+package safelock_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrengthPolicies(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		password string
+		basic    bool
+		strong   bool
+	}{
+		{"password123", false, false},
+		{"QWERTY123", false, false},
+		{"aaaaaaaaaaaa", false, false},
+		{"kettle-orbit", true, false},
+		{"kettle-Orbit-42-lantern", true, true},
+	}
+
+	for _, c := range cases {
+		basicErr := safelock.BasicPasswordPolicy.Check(c.password)
+		strongErr := safelock.StrongPasswordPolicy.Check(c.password)
+
+		assert.Equal(c.basic, basicErr == nil, c.password)
+		assert.Equal(c.strong, strongErr == nil, c.password)
+
+		if !c.strong {
+			assert.True(slErrs.Is[*slErrs.ErrWeakPassword](strongErr))
+		}
+	}
+}
+
+func TestEncryptWithPasswordPolicy(t *testing.T) {
+	assert := assert.New(t)
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	sl.PasswordPolicy = safelock.StrongPasswordPolicy
+	weakErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, "password123")
+	strongErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, "kettle-Orbit-42-lantern")
+
+	assert.True(slErrs.Is[*slErrs.ErrWeakPassword](weakErr))
+	assert.Nil(strongErr)
+}
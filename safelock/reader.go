@@ -3,10 +3,12 @@ package safelock
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/mrf345/safelock-cli/slErrs"
 )
@@ -16,22 +18,70 @@ type InputReader interface {
 	io.Seeker
 }
 
+// returns `r` as [io.Seeker] if it implements it and can actually seek (pipes can't)
+func asSeeker(r io.Reader) (seeker io.Seeker, ok bool) {
+	if seeker, ok = r.(io.Seeker); ok {
+		_, err := seeker.Seek(0, io.SeekCurrent)
+		ok = err == nil
+	}
+
+	return
+}
+
 type safelockReader struct {
 	io.Reader
 	*safelockReaderWriterBase
-	reader   InputReader
+	reader   io.Reader
+	offset   int64
+	framed   bool
 	overflow []byte
+	// encrypted chunk sizes read so far, and the size of their frames (framed chunks only)
+	read       []string
+	framesSize int
+	// size of the next frame, read ahead to know whether the current chunk is the final one
+	nextFrameSize uint32
+	started       bool
+	// number of chunks decrypted and read so far
+	chunks int
+	// chunks read ahead and being decrypted, in the order they're read
+	queue chan chan decryptedChunk
+	// limits the number of chunks decrypted concurrently
+	workers chan struct{}
+	// closed to stop reading ahead, when the reader is no longer used
+	stopped      chan struct{}
+	readingAhead bool
+	// held while reading the input, so it's no longer read once the reader is stopped
+	reading *sync.Mutex
+	// read from the encrypted header, once all chunks are read
+	metadata Metadata
+	// read by the read ahead goroutine, and passed along with the end of the chunks
+	headerMetadata Metadata
+}
+
+// decrypted chunk or the error of reading or decrypting it, the end of the chunks
+// carries the metadata read from the header
+type decryptedChunk struct {
+	data     []byte
+	err      error
+	metadata Metadata
 }
 
 func newReader(
 	pwd string,
-	reader InputReader,
+	reader io.Reader,
+	pre preamble,
 	start float64,
 	cancel context.CancelFunc,
 	aead *aeadWrapper,
 ) safelockReader {
 	return safelockReader{
-		reader: reader,
+		reader:  reader,
+		offset:  pre.size,
+		framed:  !pre.isLegacy(),
+		queue:   make(chan chan decryptedChunk, max(1, aead.config.ReadAhead)),
+		workers: make(chan struct{}, max(1, aead.config.Workers)),
+		stopped: make(chan struct{}),
+		reading: &sync.Mutex{},
 		safelockReaderWriterBase: &safelockReaderWriterBase{
 			pwd:    pwd,
 			aead:   aead,
@@ -43,119 +93,311 @@ func newReader(
 }
 
 func (sr *safelockReader) setInputSize() (err error) {
-	size, err := sr.reader.Seek(0, io.SeekEnd)
-	sr.inputSize = int(size)
+	var current, size int64
+	seeker, ok := asSeeker(sr.reader)
+
+	// streamed inputs size is unknown
+	if !ok {
+		return
+	}
+
+	if current, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+		return
+	}
+
+	if size, err = seeker.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	sr.inputSize = int(size - sr.offset)
 	sr.setHeaderSize()
+	_, err = seeker.Seek(current, io.SeekStart)
 	return
 }
 
 func (sr *safelockReader) setHeaderSize() {
-	ratio := sr.aead.config.HeaderRatio
-	size := sr.inputSize / ratio
+	sr.headerSize = getHeaderSize(sr.inputSize, sr.aead.config.HeaderRatio)
+}
 
-	if ratio > size {
-		sr.headerSize = ratio
-		return
+// size of the trailing header of legacy files, based on the size of what follows the preamble
+func getHeaderSize(inputSize, ratio int) int {
+	if size := inputSize / ratio; ratio > size {
+		return ratio
 	}
 
-	sr.headerSize = sr.inputSize / ratio
+	return inputSize / ratio
 }
 
+// reads the trailing header of legacy files, framed files header is read once all chunks are
 func (sr *safelockReader) ReadHeader() (err error) {
-	sizeDiff := int64(sr.inputSize - sr.headerSize)
+	if sr.framed {
+		return
+	}
+
+	seeker, ok := asSeeker(sr.reader)
+
+	if !ok {
+		err = fmt.Errorf("files created before format versioning can only be read from seekable inputs")
+		return sr.setErr(err)
+	}
+
+	sizeDiff := sr.offset + int64(sr.inputSize-sr.headerSize)
 	headerBytes := make([]byte, sr.headerSize)
 
-	if _, err = sr.reader.Seek(sizeDiff, io.SeekStart); err != nil {
+	if _, err = seeker.Seek(sizeDiff, io.SeekStart); err != nil {
 		err = fmt.Errorf("can't seek header > %w", err)
-		return sr.handleErr(err)
+		return sr.setErr(err)
 	}
 
 	if _, err = sr.reader.Read(headerBytes); err != nil {
 		err = fmt.Errorf("can't read header > %w", err)
-		return sr.handleErr(err)
+		return sr.setErr(err)
 	}
 
-	header := string(bytes.Trim(headerBytes, "\x00"))
-	sr.blocks = strings.Split(header, ";")[1:]
+	sr.blocks = parseHeader(headerBytes)
 
 	if len(sr.blocks) == 0 {
 		err = &slErrs.ErrFailedToAuthenticate{Msg: "missing header content"}
 		return
 	}
 
-	if _, err = sr.reader.Seek(int64(sr.aead.config.SaltLength), io.SeekStart); err != nil {
-		return sr.handleErr(err)
+	if _, err = seeker.Seek(sr.offset+int64(sr.aead.config.SaltLength), io.SeekStart); err != nil {
+		return sr.setErr(err)
 	}
 
 	return
 }
 
+func parseHeader(headerBytes []byte) []string {
+	header := string(bytes.Trim(headerBytes, "\x00"))
+	return strings.Split(header, ";")[1:]
+}
+
 func (sr *safelockReader) Read(chunk []byte) (read int, err error) {
-	var blockSize int
-	var block string
-	var decrypted []byte
+	for len(sr.overflow) == 0 {
+		if sr.overflow, err = sr.readChunk(); err != nil {
+			return
+		}
+	}
+
+	read = copy(chunk, sr.overflow)
+	sr.overflow = sr.overflow[read:]
+	return
+}
 
-	if over, done := sr.handleOverflowIn(&chunk); done {
-		return over, nil
+// returns the next decrypted chunk from the read ahead queue, errors are handled in the
+// order of the chunks they belong to, and recorded to be reported over whatever they cause
+func (sr *safelockReader) readChunk() (decrypted []byte, err error) {
+	if err = sr.getErr(); err != nil {
+		return
 	}
 
-	if len(sr.blocks) == 0 {
-		if sr.err != nil {
-			return 0, sr.err
+	if !sr.readingAhead {
+		sr.readingAhead = true
+		go sr.readAhead()
+	}
+
+	next, ok := <-sr.queue
+
+	if !ok {
+		return nil, io.EOF
+	}
+
+	chunk := <-next
+
+	if chunk.err == io.EOF {
+		sr.metadata = chunk.metadata
+		return nil, io.EOF
+	} else if chunk.err != nil {
+		return nil, sr.setErr(chunk.err)
+	}
+
+	sr.chunks += 1
+	sr.increaseOutputSize(len(chunk.data))
+	return chunk.data, nil
+}
+
+// reads the encrypted chunks in order, and queues them to be decrypted concurrently
+// until the queue is full, the input ends or fails, or the reader is stopped
+func (sr *safelockReader) readAhead() {
+	defer close(sr.queue)
+
+	// waits for the key before it's shared with the workers
+	sr.aead.getAead()
+
+	for idx := 0; ; idx++ {
+		encrypted, final, stopped, err := sr.readNextEncrypted()
+
+		if stopped {
+			return
+		}
+
+		decrypted := make(chan decryptedChunk, 1)
+
+		if err != nil {
+			decrypted <- decryptedChunk{err: err, metadata: sr.headerMetadata}
+		} else {
+			sr.workers <- struct{}{}
+
+			go func() {
+				data, err := sr.aead.decrypt(encrypted, idx, final)
+
+				if err != nil {
+					err = fmt.Errorf("can't decrypt chunk > %w", err)
+				}
+
+				decrypted <- decryptedChunk{data: data, err: err}
+				<-sr.workers
+			}()
+		}
+
+		select {
+		case sr.queue <- decrypted:
+		case <-sr.stopped:
+			return
 		}
 
-		return 0, io.EOF
+		if err != nil {
+			return
+		}
+	}
+}
+
+// stops reading ahead once the reader is no longer used, and waits for the chunk being read
+// so the input can be read by others right after
+func (sr *safelockReader) stop() {
+	close(sr.stopped)
+	sr.reading.Lock()
+	defer sr.reading.Unlock()
+}
+
+// reads the next encrypted chunk, unless the reader is stopped
+func (sr *safelockReader) readNextEncrypted() (encrypted []byte, final, stopped bool, err error) {
+	sr.reading.Lock()
+	defer sr.reading.Unlock()
+
+	select {
+	case <-sr.stopped:
+		return nil, false, true, nil
+	default:
+	}
+
+	encrypted, final, err = sr.readEncrypted()
+	return
+}
+
+func (sr *safelockReader) readEncrypted() (encrypted []byte, final bool, err error) {
+	if sr.framed {
+		return sr.readFrame()
+	}
+
+	encrypted, err = sr.readBlock()
+	return
+}
+
+func (sr *safelockReader) readBlock() (encrypted []byte, err error) {
+	var blockSize int
+	var block string
+
+	if len(sr.blocks) == 0 {
+		return nil, io.EOF
 	}
 
 	block, sr.blocks = sr.blocks[0], sr.blocks[1:]
 
 	if blockSize, err = strconv.Atoi(block); err != nil {
 		err = fmt.Errorf("invalid header block size > %w", err)
-		return 0, sr.handleErr(err)
+		return
 	}
 
-	encrypted := make([]byte, blockSize)
+	encrypted = make([]byte, blockSize)
 
-	if read, err = sr.reader.Read(encrypted); err != nil && err != io.EOF {
+	if _, err = io.ReadFull(sr.reader, encrypted); err != nil {
 		err = fmt.Errorf("cant't read encrypted chunk > %w", err)
-		return read, sr.handleErr(err)
 	}
 
-	if decrypted, err = sr.aead.decrypt(encrypted); err != nil {
-		err = fmt.Errorf("can't decrypt chunk > %w", err)
-		return read, sr.handleErr(err)
+	return
+}
+
+func (sr *safelockReader) readFrame() (encrypted []byte, final bool, err error) {
+	if !sr.started {
+		if sr.nextFrameSize, err = sr.readFrameSize(); err != nil {
+			return
+		}
+
+		sr.started = true
+	}
+
+	frameSize := sr.nextFrameSize
+
+	if frameSize == 0 {
+		// the frame before the end is the final chunk, so there has to be one
+		if len(sr.read) == 0 {
+			return nil, false, &slErrs.ErrTruncatedInput{Chunk: 0}
+		}
+
+		if err = sr.readTrailingHeader(); err != nil {
+			return
+		}
+
+		return nil, false, io.EOF
+	}
+
+	if frameSize > maxFrameSize {
+		return nil, false, &slErrs.ErrFailedToAuthenticate{Msg: "invalid chunk size"}
+	}
+
+	encrypted = make([]byte, frameSize)
+
+	if _, err = io.ReadFull(sr.reader, encrypted); err != nil {
+		return nil, false, &slErrs.ErrTruncatedInput{Chunk: len(sr.read)}
 	}
 
-	sr.outputSize += len(decrypted)
+	sr.read = append(sr.read, strconv.Itoa(int(frameSize)))
+	sr.framesSize += frameSizeLength + int(frameSize)
 
-	return sr.handleOverflowOut(&chunk, decrypted), nil
+	if sr.nextFrameSize, err = sr.readFrameSize(); err != nil {
+		return
+	}
+
+	return encrypted, sr.nextFrameSize == 0, nil
 }
 
-func (sr *safelockReader) handleOverflowIn(chunk *[]byte) (over int, done bool) {
-	if len(sr.overflow) > 0 {
-		over = copy(*chunk, sr.overflow)
-		sr.overflow = sr.overflow[over:]
-		left := len(*chunk) - over
+func (sr *safelockReader) readFrameSize() (size uint32, err error) {
+	sizeBytes := make([]byte, frameSizeLength)
 
-		if left == 0 {
-			done = true
-		}
+	if _, err = io.ReadFull(sr.reader, sizeBytes); err != nil {
+		return 0, &slErrs.ErrTruncatedInput{Chunk: len(sr.read)}
 	}
 
-	return
+	return binary.BigEndian.Uint32(sizeBytes), nil
 }
 
-func (sr *safelockReader) handleOverflowOut(chunk *[]byte, decrypted []byte) (copied int) {
-	var chunked []byte
+// reads what's left after the last frame and checks that it matches the chunks read,
+// its errors are handled along with the chunks
+func (sr *safelockReader) readTrailingHeader() (err error) {
+	var headerBytes []byte
+	var header fileHeader
+	limit := getMaxHeaderSize(sr.framesSize, len(sr.read), sr.aead.config.HeaderRatio)
+
+	if headerBytes, err = io.ReadAll(io.LimitReader(sr.reader, int64(limit)+1)); err != nil {
+		err = fmt.Errorf("can't read header > %w", err)
+		return
+	}
+
+	if len(headerBytes) > limit {
+		return &slErrs.ErrFailedToAuthenticate{Msg: "header is bigger than the encrypted content allows"}
+	}
 
-	if len(sr.overflow) > 0 {
-		chunked = append(sr.overflow, decrypted...)
-	} else {
-		chunked = decrypted
+	if header, err = openHeader(sr.aead.headerKey, sr.aead.preamble, headerBytes); err != nil {
+		return
 	}
 
-	copied = copy(*chunk, chunked)
-	sr.overflow = chunked[copied:]
+	sr.headerMetadata = header.Metadata
+
+	if header.Blocks != strings.Join(sr.read, ";") {
+		err = &slErrs.ErrFailedToAuthenticate{Msg: "header does not match encrypted content"}
+	}
 
 	return
 }
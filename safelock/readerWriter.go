@@ -2,6 +2,7 @@ package safelock
 
 import (
 	"context"
+	"sync"
 )
 
 type getPercent interface {
@@ -16,16 +17,40 @@ type safelockReaderWriterBase struct {
 	aead                              *aeadWrapper
 	start, end                        float64
 	inputSize, outputSize, headerSize int
+	// guards the error and sizes, which are updated while the progress is read
+	mu sync.Mutex
 }
 
 func (srw *safelockReaderWriterBase) handleErr(err error) error {
-	srw.err = err
+	srw.setErr(err)
 	srw.aead.errs <- err
+	return err
+}
+
+// records `err` to be returned by the following reads or writes, and cancels the rest
+func (srw *safelockReaderWriterBase) setErr(err error) error {
+	srw.mu.Lock()
+	srw.err = err
+	srw.mu.Unlock()
+
 	srw.cancel()
 	return err
 }
 
-func (srw safelockReaderWriterBase) getCompletedPercent() float64 {
+func (srw *safelockReaderWriterBase) getErr() error {
+	srw.mu.Lock()
+	defer srw.mu.Unlock()
+	return srw.err
+}
+
+func (srw *safelockReaderWriterBase) getCompletedPercent() float64 {
+	srw.mu.Lock()
+	defer srw.mu.Unlock()
+
+	if srw.inputSize == 0 {
+		return srw.start
+	}
+
 	percent := srw.start + (float64(srw.outputSize) / float64(srw.inputSize) * srw.end)
 
 	if srw.end > percent {
@@ -36,5 +61,19 @@ func (srw safelockReaderWriterBase) getCompletedPercent() float64 {
 }
 
 func (srw *safelockReaderWriterBase) increaseInputSize(increment int) {
+	srw.mu.Lock()
 	srw.inputSize += increment
+	srw.mu.Unlock()
+}
+
+func (srw *safelockReaderWriterBase) increaseOutputSize(increment int) {
+	srw.mu.Lock()
+	srw.outputSize += increment
+	srw.mu.Unlock()
+}
+
+func (srw *safelockReaderWriterBase) getOutputSize() int {
+	srw.mu.Lock()
+	defer srw.mu.Unlock()
+	return srw.outputSize
 }
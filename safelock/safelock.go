@@ -17,18 +17,38 @@ type EncryptionConfig struct {
 	KeyLength uint32
 	// encryption salt length (default: 16)
 	SaltLength int
-	// number of argon2 hashing iterations (default: 3)
+	// number of argon2 hashing iterations, up to 16 (default: 3)
 	IterationCount uint32
-	// memory allocated for generating argon2 key (default: 64 * 1024)
+	// memory in KiB allocated for generating argon2 key, up to 1024 * 1024 divided by the iterations (default: 64 * 1024)
 	MemSize uint32
-	// number of threads used to generate argon2 key (default: runtime.NumCPU())
+	// number of threads used to generate argon2 key (default: runtime.NumCPU() up to 128)
 	Threads uint8
 	// minimum password length allowed (default: 8)
 	MinPasswordLength int
+	// rules new passwords must pass, such as [safelock.StrongPasswordPolicy] (default: nil)
+	PasswordPolicy PasswordPolicy
+	// number of chunks encrypted or decrypted concurrently (default: runtime.NumCPU())
+	Workers int
+	// number of chunks read and decrypted ahead of being extracted, each taking up to
+	// a megabyte of memory (default: runtime.NumCPU() * 2)
+	ReadAhead int
 	// ratio to create file header size based on (default: 1024 * 4)
 	HeaderRatio int
+	// public keys that can decrypt encrypted files, along with the password if not empty (default: nil)
+	Recipients []*Recipient
+	// private keys to decrypt files encrypted to their recipients, tried before the password (default: nil)
+	Identities []*Identity
+	// leave the keyfile set with [safelock.EncryptionConfig.SetKeyfile] out of key slots added by
+	// [safelock.Safelock.AddPasswordSlot] and [safelock.Safelock.Rekey], which keep it otherwise,
+	// unless another one is set with [safelock.EncryptionConfig.SetNewKeyfile] (default: false)
+	DropKeyfile bool
+	// details stored in the encrypted header of encrypted files, read with
+	// [safelock.Safelock.ReadMetadata] (default: nil)
+	Metadata *Metadata
 
-	random chan []byte
+	random         chan []byte
+	keyfileHash    []byte
+	newKeyfileHash []byte
 }
 
 func (ec *EncryptionConfig) loadRandom(errs chan error) {
@@ -50,6 +70,19 @@ type ArchiverConfig struct {
 	Compression archiver.Compression
 	// files archiving (default: tar)
 	Archival archiver.Archival
+	// how symbolic links are archived (default: LinksStore)
+	Links LinksPolicy
+	// glob patterns of archive paths to encrypt, everything is encrypted if empty (default: nil)
+	EncryptInclude []string
+	// glob patterns of archive paths to skip encrypting (default: nil)
+	EncryptExclude []string
+	// name of files within input directories that list patterns to skip encrypting,
+	// relative to the directory they're in (default: ".safelockignore")
+	IgnoreFile string
+}
+
+func (ac ArchiverConfig) getEncryptFilter() pathFilter {
+	return pathFilter{include: ac.EncryptInclude, exclude: ac.EncryptExclude}
 }
 
 func (ac *ArchiverConfig) archive(ctx context.Context, output io.Writer, files []archiver.File) error {
@@ -57,10 +90,31 @@ func (ac *ArchiverConfig) archive(ctx context.Context, output io.Writer, files [
 		Archive(ctx, output, files)
 }
 
+// decryption extraction configuration settings
+type DecryptionConfig struct {
+	// glob patterns of archive paths to extract, everything is extracted if empty (default: nil)
+	Include []string
+	// glob patterns of archive paths to skip extracting (default: nil)
+	Exclude []string
+	// restore files permissions (default: true)
+	PreservePermissions bool
+	// restore files access and modification times (default: true)
+	PreserveTimes bool
+	// restore files user and group ids, only applies when running as root (default: false)
+	PreserveOwnership bool
+	// how files that already exist in the output path are handled (default: ConflictOverwrite)
+	OnConflict ConflictPolicy
+}
+
+func (dc DecryptionConfig) getFilter() pathFilter {
+	return pathFilter{include: dc.Include, exclude: dc.Exclude}
+}
+
 // the main object used to configure safelock
 type Safelock struct {
 	EncryptionConfig
 	ArchiverConfig
+	DecryptionConfig
 
 	// disable all output and logs (default: false)
 	Quiet bool
@@ -72,7 +126,9 @@ type Safelock struct {
 func New() *Safelock {
 	return &Safelock{
 		ArchiverConfig: ArchiverConfig{
-			Archival: archiver.Tar{},
+			Archival:   archiver.Tar{},
+			Links:      LinksStore,
+			IgnoreFile: ".safelockignore",
 			Compression: archiver.Zstd{
 				EncoderOptions: []zstd.EOption{
 					zstd.WithEncoderLevel(zstd.SpeedFastest),
@@ -86,9 +142,16 @@ func New() *Safelock {
 			MinPasswordLength: 8,
 			HeaderRatio:       1024 * 4,
 			MemSize:           64 * 1024,
-			Threads:           uint8(runtime.NumCPU()),
+			Threads:           uint8(min(runtime.NumCPU(), int(maxThreads))),
+			Workers:           runtime.NumCPU(),
+			ReadAhead:         runtime.NumCPU() * 2,
 			random:            make(chan []byte, 500),
 		},
+		DecryptionConfig: DecryptionConfig{
+			PreservePermissions: true,
+			PreserveTimes:       true,
+			OnConflict:          ConflictOverwrite,
+		},
 		StatusObs: NewStatusObs(),
 	}
 }
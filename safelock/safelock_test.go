@@ -1,6 +1,9 @@
 package safelock_test
 
 import (
+	"context"
+	"io"
+
 	"github.com/mholt/archiver/v4"
 	"github.com/mrf345/safelock-cli/safelock"
 )
@@ -17,3 +20,24 @@ func GetQuietGzipSafelock() *safelock.Safelock {
 	sl.Quiet = true
 	return sl
 }
+
+// tar archival that renames archived files, to craft malicious archives
+type RenamingTar struct {
+	archiver.Tar
+	NewName string
+}
+
+func (rt RenamingTar) Archive(ctx context.Context, output io.Writer, files []archiver.File) error {
+	for idx := range files {
+		files[idx].NameInArchive = rt.NewName
+	}
+
+	return rt.Tar.Archive(ctx, output, files)
+}
+
+func GetQuietRenamingSafelock(name string) *safelock.Safelock {
+	sl := safelock.New()
+	sl.Archival = RenamingTar{NewName: name}
+	sl.Quiet = true
+	return sl
+}
@@ -0,0 +1,258 @@
+package safelock
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/mrf345/safelock-cli/slErrs"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// number of key slots in every encrypted file, used or not, so they can be changed in place
+const keySlotsCount = 8
+
+// size of the random key that encrypts files content
+const fileKeyLength = chacha20poly1305.KeySize
+
+// biggest salt that fits in password key slots
+const maxSaltLength = 32
+
+// size of the encrypted file key (nonce + key + tag)
+const wrappedKeyLength = chacha20poly1305.NonceSizeX + fileKeyLength + chacha20poly1305.Overhead
+
+// derives recipient slots wrapping keys
+var recipientKeyInfo = []byte("safelock-cli x25519")
+
+type keySlotType uint8
+
+const (
+	emptySlot keySlotType = iota
+	passwordSlot
+	recipientSlot
+)
+
+// a copy of the file key, encrypted with a key derived from a password or shared with a recipient
+type keySlot struct {
+	Type keySlotType
+	// salt of password slots, or ephemeral public key of recipient slots
+	Data [32]byte
+	// nonce followed by the encrypted file key
+	Wrapped [wrappedKeyLength]byte
+	// spare space, for slots to keep the same size as they change
+	_ [23]byte
+}
+
+// all key slots of a file, any of which can unlock it
+type keySlots [keySlotsCount]keySlot
+
+// creates the key slots of a new file, for the password or keyfile if set and each of the recipients
+func newKeySlots(pwd string, config EncryptionConfig, fileKey []byte) (slots keySlots, err error) {
+	var slot keySlot
+
+	if config.getSecret(pwd) != nil {
+		if slot, err = newPasswordSlot(pwd, config, fileKey); err != nil {
+			return
+		}
+
+		if err = slots.add(slot); err != nil {
+			return
+		}
+	}
+
+	for _, recipient := range config.Recipients {
+		if slot, err = newRecipientSlot(recipient, fileKey); err != nil {
+			return
+		}
+
+		if err = slots.add(slot); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func newPasswordSlot(pwd string, config EncryptionConfig, fileKey []byte) (slot keySlot, err error) {
+	slot.Type = passwordSlot
+
+	if _, err = rand.Read(slot.Data[:config.SaltLength]); err != nil {
+		err = fmt.Errorf("failed to generate salt > %w", err)
+		return
+	}
+
+	err = slot.wrap(slot.getPasswordKey(pwd, config), fileKey)
+	return
+}
+
+func newRecipientSlot(recipient *Recipient, fileKey []byte) (slot keySlot, err error) {
+	var ephemeral *ecdh.PrivateKey
+	var shared []byte
+
+	if ephemeral, err = ecdh.X25519().GenerateKey(rand.Reader); err != nil {
+		err = fmt.Errorf("failed to generate ephemeral key > %w", err)
+		return
+	}
+
+	if shared, err = ephemeral.ECDH(recipient.key); err != nil {
+		err = fmt.Errorf("failed to share key with recipient > %w", err)
+		return
+	}
+
+	slot.Type = recipientSlot
+	copy(slot.Data[:], ephemeral.PublicKey().Bytes())
+	err = slot.wrap(getRecipientKey(shared, slot.Data[:], recipient.key.Bytes()), fileKey)
+	return
+}
+
+func (ks keySlot) getPasswordKey(pwd string, config EncryptionConfig) []byte {
+	return argon2.IDKey(
+		config.getSecret(pwd),
+		ks.Data[:config.SaltLength],
+		config.IterationCount,
+		config.MemSize,
+		config.Threads,
+		config.KeyLength,
+	)
+}
+
+func getRecipientKey(shared, ephemeral, recipient []byte) []byte {
+	key := make([]byte, fileKeyLength)
+	salt := append(append([]byte{}, ephemeral...), recipient...)
+	_, _ = io.ReadFull(hkdf.New(sha256.New, shared, salt, recipientKeyInfo), key)
+	return key
+}
+
+// slot type and data are authenticated along with the file key
+func (ks keySlot) additionalData() []byte {
+	return append([]byte{byte(ks.Type)}, ks.Data[:]...)
+}
+
+func (ks *keySlot) wrap(key, fileKey []byte) (err error) {
+	var aead cipher.AEAD
+	nonce := ks.Wrapped[:chacha20poly1305.NonceSizeX]
+
+	if aead, err = chacha20poly1305.NewX(key); err != nil {
+		return fmt.Errorf("failed to create AEAD > %w", err)
+	}
+
+	if _, err = rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce > %w", err)
+	}
+
+	aead.Seal(ks.Wrapped[len(nonce):len(nonce)], nonce, fileKey, ks.additionalData())
+	return
+}
+
+func (ks keySlot) unwrap(key []byte) (fileKey []byte, ok bool) {
+	aead, err := chacha20poly1305.NewX(key)
+
+	if err != nil {
+		return
+	}
+
+	nonce := ks.Wrapped[:chacha20poly1305.NonceSizeX]
+	fileKey, err = aead.Open(nil, nonce, ks.Wrapped[len(nonce):], ks.additionalData())
+	return fileKey, err == nil
+}
+
+func (ks keySlot) unlockWithPassword(pwd string, config EncryptionConfig) ([]byte, bool) {
+	if ks.Type != passwordSlot || config.getSecret(pwd) == nil {
+		return nil, false
+	}
+
+	return ks.unwrap(ks.getPasswordKey(pwd, config))
+}
+
+func (ks keySlot) unlockWithIdentity(identity *Identity) ([]byte, bool) {
+	if ks.Type != recipientSlot {
+		return nil, false
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(ks.Data[:])
+
+	if err != nil {
+		return nil, false
+	}
+
+	shared, err := identity.key.ECDH(ephemeral)
+
+	if err != nil {
+		return nil, false
+	}
+
+	return ks.unwrap(getRecipientKey(shared, ks.Data[:], identity.key.PublicKey().Bytes()))
+}
+
+// adds `slot` to the first empty slot
+func (slots *keySlots) add(slot keySlot) error {
+	idx := slots.freeIndex()
+
+	if idx == -1 {
+		return fmt.Errorf("all (%d) key slots are used", keySlotsCount)
+	}
+
+	slots[idx] = slot
+	return nil
+}
+
+// index of the first empty slot, or -1 if all are used
+func (slots keySlots) freeIndex() int {
+	for idx, slot := range slots {
+		if slot.Type == emptySlot {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// gets the file key from the first slot that the identities or the password unlock,
+// identities are tried first since they're way faster
+func (slots keySlots) unlock(pwd string, config EncryptionConfig) (fileKey []byte, idx int, err error) {
+	var ok bool
+
+	for _, identity := range config.Identities {
+		for idx = range slots {
+			if fileKey, ok = slots[idx].unlockWithIdentity(identity); ok {
+				return
+			}
+		}
+	}
+
+	for idx = range slots {
+		if fileKey, ok = slots[idx].unlockWithPassword(pwd, config); ok {
+			return
+		}
+	}
+
+	err = &slErrs.ErrNoMatchingKey{Msg: "no key slot matches the password or identities"}
+	return
+}
+
+func (ks keySlot) bytes() []byte {
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.BigEndian, ks)
+	return buf.Bytes()
+}
+
+func (slots keySlots) bytes() []byte {
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.BigEndian, slots)
+	return buf.Bytes()
+}
+
+func readKeySlots(r io.Reader) (slots keySlots, err error) {
+	if err = binary.Read(r, binary.BigEndian, &slots); err != nil {
+		err = &slErrs.ErrFailedToAuthenticate{Msg: "incomplete key slots"}
+	}
+
+	return
+}
@@ -0,0 +1,264 @@
+package safelock
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mrf345/safelock-cli/slErrs"
+)
+
+// encrypted file whose key slots can be changed in place, such as [os.File]
+type KeySlotsFile interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// details of a used key slot within an encrypted file
+type KeySlotInfo struct {
+	// position of the slot, used to remove it
+	Index int `json:"index"`
+	// what unlocks the slot, either "password" or "recipient"
+	Type string `json:"type"`
+}
+
+var keySlotTypeNames = map[keySlotType]string{
+	passwordSlot:  "password",
+	recipientSlot: "recipient",
+}
+
+// key slots read from a file, and unlocked
+type unlockedSlots struct {
+	keySlots
+	pre    preamble
+	config EncryptionConfig
+	// file key and the index of the slot that unlocked it
+	fileKey []byte
+	idx     int
+}
+
+// lists the used key slots of `input` which must be an object that implements [io.ReaderAt] such as [os.File]
+func (sl *Safelock) ListKeySlots(input io.ReaderAt) (slots []KeySlotInfo, err error) {
+	var read unlockedSlots
+
+	if read, err = readFileKeySlots(input); err != nil {
+		return
+	}
+
+	return read.infos(), nil
+}
+
+// adds a key slot to `file` so that `newPassword` can decrypt it too, without re-encrypting its content,
+// `password` or [safelock.EncryptionConfig.Identities] must unlock one of its existing slots, and the new slot
+// keeps the keyfile that unlocks it, unless changed with [safelock.EncryptionConfig.SetNewKeyfile] or
+// dropped with [safelock.EncryptionConfig.DropKeyfile]
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) AddPasswordSlot(ctx context.Context, file KeySlotsFile, password, newPassword string) error {
+	if err := sl.validateNewPassword(newPassword); err != nil {
+		return err
+	}
+
+	return sl.addKeySlot(ctx, file, password, func(unlocked unlockedSlots) (keySlot, error) {
+		return newPasswordSlot(newPassword, sl.newSlotConfig(unlocked), unlocked.fileKey)
+	})
+}
+
+// adds a key slot to `file` so that `recipient` identity can decrypt it too, without re-encrypting its content,
+// `password` or [safelock.EncryptionConfig.Identities] must unlock one of its existing slots
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) AddRecipientSlot(
+	ctx context.Context,
+	file KeySlotsFile,
+	password string,
+	recipient *Recipient,
+) error {
+	return sl.addKeySlot(ctx, file, password, func(unlocked unlockedSlots) (keySlot, error) {
+		return newRecipientSlot(recipient, unlocked.fileKey)
+	})
+}
+
+// removes the key slot at `idx` of `file`, so it can no longer decrypt it,
+// `password` or [safelock.EncryptionConfig.Identities] must unlock one of its slots
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) RemoveKeySlot(ctx context.Context, file KeySlotsFile, password string, idx int) (err error) {
+	var unlocked unlockedSlots
+
+	if unlocked, err = sl.unlockFileKeySlots(ctx, file, password); err != nil {
+		return
+	}
+
+	if idx < 0 || idx >= keySlotsCount || unlocked.keySlots[idx].Type == emptySlot {
+		return fmt.Errorf("key slot (%d) is not used", idx)
+	}
+
+	if unlocked.usedCount() == 1 {
+		return fmt.Errorf("can't remove the last key slot, the file would no longer be decryptable")
+	}
+
+	return unlocked.writeSlot(file, idx, keySlot{})
+}
+
+// replaces the key slot of `oldPassword` in `file` with one of `newPassword`, without re-encrypting its content,
+// the new slot is written before the old one is wiped, so the file stays decryptable with either if interrupted,
+// and it keeps the keyfile that unlocks it, unless changed with [safelock.EncryptionConfig.SetNewKeyfile] or
+// dropped with [safelock.EncryptionConfig.DropKeyfile]
+//
+// NOTE: the file content key stays the same, so re-encrypt files whose content or key might've been exposed
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) Rekey(ctx context.Context, file KeySlotsFile, oldPassword, newPassword string) (err error) {
+	var unlocked unlockedSlots
+	var slot keySlot
+	var passwordSl = *sl
+
+	if err = sl.validateNewPassword(newPassword); err != nil {
+		return
+	}
+
+	// only the old password slot is replaced, not the identities ones
+	passwordSl.Identities = nil
+
+	if unlocked, err = passwordSl.unlockFileKeySlots(ctx, file, oldPassword); err != nil {
+		return
+	}
+
+	freeIdx := unlocked.freeIndex()
+
+	if freeIdx == -1 {
+		return fmt.Errorf("all (%d) key slots are used, remove one to rekey", keySlotsCount)
+	}
+
+	if slot, err = newPasswordSlot(newPassword, sl.newSlotConfig(unlocked), unlocked.fileKey); err != nil {
+		return fmt.Errorf("failed to create key slot > %w", err)
+	}
+
+	if err = unlocked.writeSlot(file, freeIdx, slot); err != nil {
+		return
+	}
+
+	return unlocked.writeSlot(file, unlocked.idx, keySlot{})
+}
+
+// new passwords can only be left out if a new keyfile is used instead
+func (sl *Safelock) validateNewPassword(pwd string) error {
+	if len(pwd) < sl.MinPasswordLength && (pwd != "" || !sl.hasNewKeyfile()) {
+		return &slErrs.ErrInvalidPassword{Len: len(pwd), Need: sl.MinPasswordLength}
+	}
+
+	return sl.checkPasswordPolicy(pwd)
+}
+
+// the unlocked file settings with the keyfile of added slots in place of the one that unlocked it
+func (sl *Safelock) newSlotConfig(unlocked unlockedSlots) (config EncryptionConfig) {
+	config = unlocked.config
+	config.keyfileHash = sl.getNewKeyfileHash()
+	return
+}
+
+func (sl *Safelock) addKeySlot(
+	ctx context.Context,
+	file KeySlotsFile,
+	password string,
+	newSlot func(unlockedSlots) (keySlot, error),
+) (err error) {
+	var unlocked unlockedSlots
+	var slot keySlot
+
+	if unlocked, err = sl.unlockFileKeySlots(ctx, file, password); err != nil {
+		return
+	}
+
+	if slot, err = newSlot(unlocked); err != nil {
+		return fmt.Errorf("failed to create key slot > %w", err)
+	}
+
+	if idx := unlocked.freeIndex(); idx != -1 {
+		return unlocked.writeSlot(file, idx, slot)
+	}
+
+	return fmt.Errorf("all (%d) key slots are used", keySlotsCount)
+}
+
+func (sl *Safelock) unlockFileKeySlots(
+	ctx context.Context,
+	file io.ReaderAt,
+	password string,
+) (unlocked unlockedSlots, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if unlocked, err = readFileKeySlots(file); err != nil {
+		return
+	}
+
+	unlocked.config = sl.EncryptionConfig
+	unlocked.pre.configureEncryption(&unlocked.config)
+
+	if unlocked.fileKey, unlocked.idx, err = unlocked.unlock(password, unlocked.config); err != nil {
+		return
+	}
+
+	err = ctx.Err()
+	return
+}
+
+// details of the used slots
+func (slots keySlots) infos() (infos []KeySlotInfo) {
+	for idx, slot := range slots {
+		if slot.Type != emptySlot {
+			infos = append(infos, KeySlotInfo{Index: idx, Type: keySlotTypeNames[slot.Type]})
+		}
+	}
+
+	return
+}
+
+func readFileKeySlots(file io.ReaderAt) (read unlockedSlots, err error) {
+	input := io.NewSectionReader(file, 0, math.MaxInt64)
+
+	if read.pre, err = readPreamble(input); err != nil {
+		err = fmt.Errorf("failed to read input preamble > %w", err)
+		return
+	}
+
+	if read.pre.isLegacy() {
+		err = fmt.Errorf("files created before format versioning have no key slots, re-encrypt them")
+		return
+	}
+
+	read.keySlots, err = readKeySlots(input)
+	return
+}
+
+func (us unlockedSlots) usedCount() (count int) {
+	for _, slot := range us.keySlots {
+		if slot.Type != emptySlot {
+			count += 1
+		}
+	}
+
+	return
+}
+
+// writes `slot` in place of the slot at `idx` and flushes it to disk if possible
+func (us unlockedSlots) writeSlot(file KeySlotsFile, idx int, slot keySlot) (err error) {
+	offset := us.pre.size + int64(idx*binary.Size(slot))
+
+	if _, err = file.WriteAt(slot.bytes(), offset); err != nil {
+		return fmt.Errorf("failed to write key slot > %w", err)
+	}
+
+	if syncer, ok := file.(interface{ Sync() error }); ok {
+		if err = syncer.Sync(); err != nil {
+			return fmt.Errorf("failed to write key slot > %w", err)
+		}
+	}
+
+	return
+}
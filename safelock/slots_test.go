@@ -0,0 +1,187 @@
+package safelock_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrf345/safelock-cli/safelock"
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAndRemoveKeySlots(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	newPassword := "new_testing123456"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	addErr := sl.AddPasswordSlot(context.TODO(), outputFile, password, newPassword)
+	slots, listErr := sl.ListKeySlots(outputFile)
+	newDecErr := sl.Decrypt(context.TODO(), outputFile, outputDir, newPassword)
+	removeErr := sl.RemoveKeySlot(context.TODO(), outputFile, newPassword, 0)
+	oldDecErr := sl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	lastRemoveErr := sl.RemoveKeySlot(context.TODO(), outputFile, newPassword, 1)
+	remainingSlots, _ := sl.ListKeySlots(outputFile)
+
+	assert.Nil(encErr)
+	assert.Nil(addErr)
+	assert.Nil(listErr)
+	assert.Equal([]safelock.KeySlotInfo{{Index: 0, Type: "password"}, {Index: 1, Type: "password"}}, slots)
+	assert.Nil(newDecErr)
+	assert.Nil(removeErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](oldDecErr))
+	assert.NotNil(lastRemoveErr)
+	assert.Equal([]safelock.KeySlotInfo{{Index: 1, Type: "password"}}, remainingSlots)
+}
+
+func TestAddRecipientSlot(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	identity, _ := safelock.GenerateIdentity()
+	encSl := GetQuietSafelock()
+	decSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	decSl.Identities = []*safelock.Identity{identity}
+	encErr := encSl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	wrongErr := encSl.AddRecipientSlot(context.TODO(), outputFile, "wrong_password", identity.Recipient())
+	addErr := encSl.AddRecipientSlot(context.TODO(), outputFile, password, identity.Recipient())
+	decErr := decSl.Decrypt(context.TODO(), outputFile, outputDir, "")
+
+	assert.Nil(encErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](wrongErr))
+	assert.Nil(addErr)
+	assert.Nil(decErr)
+}
+
+func TestRekey(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	newPassword := "new_testing123456"
+	content := "Hello World!"
+	sl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+	decryptedPath := filepath.Join(outputDir, filepath.Base(inputFile.Name()))
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	_, _ = inputFile.WriteString(content)
+	inputFile.Close()
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	wrongErr := sl.Rekey(context.TODO(), outputFile, "wrong_password", newPassword)
+	shortErr := sl.Rekey(context.TODO(), outputFile, password, "short")
+	rekeyErr := sl.Rekey(context.TODO(), outputFile, password, newPassword)
+	oldDecErr := sl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	newDecErr := sl.Decrypt(context.TODO(), outputFile, outputDir, newPassword)
+	slots, _ := sl.ListKeySlots(outputFile)
+	decrypted, _ := os.ReadFile(decryptedPath)
+
+	assert.Nil(encErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](wrongErr))
+	assert.True(slErrs.Is[*slErrs.ErrInvalidPassword](shortErr))
+	assert.Nil(rekeyErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](oldDecErr))
+	assert.Nil(newDecErr)
+	assert.Len(slots, 1)
+	assert.Equal(content, string(decrypted))
+}
+
+func TestAddKeyfileSlot(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	keyfile := new(bytes.Buffer)
+	sl := GetQuietSafelock()
+	keyfileSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	_ = safelock.GenerateKeyfile(keyfile)
+	_ = sl.SetNewKeyfile(bytes.NewReader(keyfile.Bytes()))
+	_ = keyfileSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	addErr := sl.AddPasswordSlot(context.TODO(), outputFile, password, "")
+	keyfileDecErr := keyfileSl.Decrypt(context.TODO(), outputFile, outputDir, "")
+	passwordDecErr := sl.Decrypt(context.TODO(), outputFile, outputDir, password)
+
+	assert.Nil(encErr)
+	assert.Nil(addErr)
+	assert.Nil(keyfileDecErr)
+	assert.Nil(passwordDecErr)
+}
+
+func TestRekeyKeyfile(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	newPassword := "new_testing123456"
+	keyfile := new(bytes.Buffer)
+	addSl := GetQuietSafelock()
+	keepSl := GetQuietSafelock()
+	removeSl := GetQuietSafelock()
+	passwordSl := GetQuietSafelock()
+	keyfileSl := GetQuietSafelock()
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputDir, _ := os.MkdirTemp("", "output_dir")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(outputFile.Name())
+	inputFile.Close()
+
+	_ = safelock.GenerateKeyfile(keyfile)
+	_ = addSl.SetNewKeyfile(bytes.NewReader(keyfile.Bytes()))
+	_ = keepSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+	_ = removeSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+	_ = keyfileSl.SetKeyfile(bytes.NewReader(keyfile.Bytes()))
+	removeSl.DropKeyfile = true
+
+	encErr := passwordSl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	addErr := addSl.Rekey(context.TODO(), outputFile, password, newPassword)
+	passwordOnlyErr := passwordSl.Decrypt(context.TODO(), outputFile, outputDir, newPassword)
+	keyfileDecErr := keyfileSl.Decrypt(context.TODO(), outputFile, outputDir, newPassword)
+	keepErr := keepSl.Rekey(context.TODO(), outputFile, newPassword, password)
+	keptPasswordOnlyErr := passwordSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	keptKeyfileDecErr := keyfileSl.Decrypt(context.TODO(), outputFile, outputDir, password)
+	removeErr := removeSl.Rekey(context.TODO(), outputFile, password, newPassword)
+	passwordDecErr := passwordSl.Decrypt(context.TODO(), outputFile, outputDir, newPassword)
+
+	assert.Nil(encErr)
+	assert.Nil(addErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](passwordOnlyErr))
+	assert.Nil(keyfileDecErr)
+	assert.Nil(keepErr)
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](keptPasswordOnlyErr))
+	assert.Nil(keptKeyfileDecErr)
+	assert.Nil(removeErr)
+	assert.Nil(passwordDecErr)
+}
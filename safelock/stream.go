@@ -0,0 +1,146 @@
+package safelock
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mholt/archiver/v4"
+)
+
+// archival format of a single entry with unknown size (such as stdin), that's written as is
+// after a small header of its name, mode and modification time
+type streamArchival struct{}
+
+type streamHeader struct {
+	NameLength uint16
+	Mode       uint32
+	ModTime    int64
+}
+
+func (streamArchival) Name() string { return ".stream" }
+
+func (streamArchival) Match(filename string, stream io.Reader) (archiver.MatchResult, error) {
+	return archiver.MatchResult{}, nil
+}
+
+func (streamArchival) Archive(ctx context.Context, output io.Writer, files []archiver.File) (err error) {
+	var reader io.ReadCloser
+
+	if len(files) != 1 {
+		return fmt.Errorf("stream archives must contain one file, got (%d)", len(files))
+	}
+
+	file := files[0]
+	header := streamHeader{
+		NameLength: uint16(len(file.NameInArchive)),
+		Mode:       uint32(file.Mode()),
+		ModTime:    file.ModTime().Unix(),
+	}
+
+	if err = binary.Write(output, binary.BigEndian, header); err != nil {
+		return fmt.Errorf("failed to write stream header > %w", err)
+	}
+
+	if _, err = io.WriteString(output, file.NameInArchive); err != nil {
+		return fmt.Errorf("failed to write stream header > %w", err)
+	}
+
+	if reader, err = file.Open(); err != nil {
+		return fmt.Errorf("failed to open stream > %w", err)
+	}
+	defer reader.Close()
+
+	if _, err = io.Copy(output, reader); err != nil {
+		return fmt.Errorf("failed to write stream > %w", err)
+	}
+
+	return ctx.Err()
+}
+
+func (streamArchival) Extract(
+	ctx context.Context,
+	source io.Reader,
+	pathsInArchive []string,
+	handleFile archiver.FileHandler,
+) (err error) {
+	var header streamHeader
+
+	if err = binary.Read(source, binary.BigEndian, &header); err != nil {
+		return fmt.Errorf("failed to read stream header > %w", err)
+	}
+
+	name := make([]byte, header.NameLength)
+
+	if _, err = io.ReadFull(source, name); err != nil {
+		return fmt.Errorf("failed to read stream header > %w", err)
+	}
+
+	info := streamFileInfo{
+		name:    string(name),
+		mode:    fs.FileMode(header.Mode),
+		modTime: time.Unix(header.ModTime, 0),
+	}
+
+	if !isPathIncluded(pathsInArchive, info.name) {
+		return
+	}
+
+	return handleFile(ctx, archiver.File{
+		FileInfo:      info,
+		NameInArchive: info.name,
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(source), nil
+		},
+	})
+}
+
+func isPathIncluded(paths []string, name string) bool {
+	if paths == nil {
+		return true
+	}
+
+	for _, p := range paths {
+		if name == p || strings.HasPrefix(name, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// creates an archive file that reads `input` until it ends
+func newStreamFile(input io.Reader, name string) archiver.File {
+	info := streamFileInfo{
+		name:    path.Clean("/" + name)[1:],
+		mode:    0644,
+		modTime: time.Now(),
+	}
+
+	return archiver.File{
+		FileInfo:      info,
+		NameInArchive: info.name,
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(input), nil
+		},
+	}
+}
+
+// file info of a stream whose size isn't known ahead
+type streamFileInfo struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (si streamFileInfo) Name() string       { return path.Base(si.name) }
+func (si streamFileInfo) Size() int64        { return 0 }
+func (si streamFileInfo) Mode() fs.FileMode  { return si.mode }
+func (si streamFileInfo) ModTime() time.Time { return si.modTime }
+func (si streamFileInfo) IsDir() bool        { return si.mode.IsDir() }
+func (si streamFileInfo) Sys() any           { return nil }
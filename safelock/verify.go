@@ -0,0 +1,53 @@
+package safelock
+
+import (
+	"context"
+	"io"
+
+	"github.com/mholt/archiver/v4"
+)
+
+// summary of a verified encrypted file
+type VerifyReport struct {
+	// number of files and directories within the archive
+	Entries int `json:"entries"`
+	// total size of the files content in bytes
+	Size int64 `json:"size"`
+	// number of authenticated encrypted chunks
+	Chunks int `json:"chunks"`
+	// details stored in the encrypted header, empty for files created before versioning
+	Metadata Metadata `json:"metadata"`
+}
+
+// checks that `input` which must be an object that implements [io.Reader] such as [os.File] decrypts,
+// authenticating all of its chunks, decompressing and walking through its files without writing them,
+// failing chunks are reported through [slErrs.ErrFailedToAuthenticate]
+//
+// NOTE: `ctx` context is optional you can pass `nil` and the method will handle it
+func (sl *Safelock) Verify(ctx context.Context, input io.Reader, password string) (report VerifyReport, err error) {
+	err = sl.extract(ctx, input, password, extraction{
+		act:  "Verifying",
+		done: "All set and verified!",
+		handleFile: func(ctx context.Context, file archiver.File) (err error) {
+			var entry ArchiveEntry
+
+			if entry, err = newArchiveEntry(file); err != nil {
+				return
+			}
+
+			report.Entries += 1
+
+			if entry.Mode.IsRegular() {
+				report.Size += entry.Size
+			}
+
+			return
+		},
+		finishRead: func(reader *safelockReader) {
+			report.Chunks = reader.chunks
+			report.Metadata = reader.metadata
+		},
+	})
+
+	return
+}
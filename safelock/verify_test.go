@@ -0,0 +1,52 @@
+package safelock_test
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"testing"
+
+	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	assert := assert.New(t)
+	password := "testing123456"
+	sl := GetQuietSafelock()
+	content := make([]byte, 1024*1024)
+	inputFile, _ := os.CreateTemp("", "input_file")
+	outputFile, _ := os.CreateTemp("", "output_file.sla")
+
+	defer os.Remove(inputFile.Name())
+	defer os.Remove(outputFile.Name())
+
+	_, _ = rand.Read(content)
+	_, _ = inputFile.Write(content)
+	inputFile.Close()
+
+	encErr := sl.Encrypt(context.TODO(), []string{inputFile.Name()}, outputFile, password)
+	report, verifyErr := sl.Verify(context.TODO(), outputFile, password)
+	info, _ := outputFile.Stat()
+
+	assert.Nil(encErr)
+	assert.Nil(verifyErr)
+	assert.Equal(1, report.Entries)
+	assert.Equal(int64(len(content)), report.Size)
+	assert.Greater(report.Chunks, 1)
+
+	// flip a byte in the middle of the encrypted content
+	flipped := make([]byte, 1)
+	_, _ = outputFile.ReadAt(flipped, info.Size()/2)
+	_, _ = outputFile.WriteAt([]byte{flipped[0] ^ 1}, info.Size()/2)
+	_, corruptedErr := sl.Verify(context.TODO(), outputFile, password)
+	_, _ = outputFile.WriteAt(flipped, info.Size()/2)
+
+	_ = outputFile.Truncate(info.Size() / 2)
+	_, truncatedErr := sl.Verify(context.TODO(), outputFile, password)
+
+	assert.True(slErrs.Is[*slErrs.ErrFailedToAuthenticate](corruptedErr))
+	assert.Contains(corruptedErr.Error(), "chunk (")
+	assert.False(slErrs.Is[*slErrs.ErrNoMatchingKey](corruptedErr))
+	assert.True(slErrs.Is[*slErrs.ErrTruncatedInput](truncatedErr))
+}
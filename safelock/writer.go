@@ -2,15 +2,38 @@ package safelock
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 )
 
+// size of the length prefix that frames every encrypted chunk
+const frameSizeLength = 4
+
+// size of the plain chunks encrypted at once, only the final chunk can be smaller
+const maxChunkSize = 1024 * 1024
+
+// biggest encrypted chunk accepted (plain chunk + nonce + tag)
+const maxFrameSize = maxChunkSize + 1024
+
 type safelockWriter struct {
 	io.Writer
 	*safelockReaderWriterBase
 	writer io.Writer
+	// last chunk written, encrypted once it's full or known to be the final one
+	pending []byte
+	// index of the next chunk to encrypt
+	counter int
+	// chunks being encrypted, in the order they're written
+	queue chan chan []byte
+	// limits the number of chunks encrypted concurrently
+	workers chan struct{}
+	// receives the first error of writing the queued chunks, once they're all written
+	written chan error
+	// closes the queue once, whether all chunks were submitted or encryption stopped early
+	closeQueue sync.Once
 }
 
 func newWriter(
@@ -19,9 +42,15 @@ func newWriter(
 	start float64,
 	cancel context.CancelFunc,
 	aead *aeadWrapper,
-) safelockWriter {
-	return safelockWriter{
-		writer: writer,
+) *safelockWriter {
+	workers := max(1, aead.config.Workers)
+
+	return &safelockWriter{
+		writer:  writer,
+		pending: make([]byte, 0, maxChunkSize),
+		queue:   make(chan chan []byte, workers),
+		workers: make(chan struct{}, workers),
+		written: make(chan error, 1),
 		safelockReaderWriterBase: &safelockReaderWriterBase{
 			aead:   aead,
 			pwd:    pwd,
@@ -33,29 +62,107 @@ func newWriter(
 }
 
 func (sw *safelockWriter) Write(chunk []byte) (written int, err error) {
-	if written, err = sw.writer.Write(sw.aead.encrypt(chunk)); err != nil {
+	// stops archiving as soon as the output fails
+	if err = sw.getErr(); err != nil {
+		return
+	}
+
+	for len(chunk) > written {
+		// a full chunk followed by more data can't be the final one
+		if len(sw.pending) == maxChunkSize {
+			sw.submit(sw.pending, false)
+			sw.pending = make([]byte, 0, maxChunkSize)
+		}
+
+		part := chunk[written:min(len(chunk), written+maxChunkSize-len(sw.pending))]
+		sw.pending = append(sw.pending, part...)
+		written += len(part)
+	}
+
+	return
+}
+
+// queues `chunk` to be encrypted by the next available worker, while its position
+// and whether it's the final one are set in the order chunks are submitted
+func (sw *safelockWriter) submit(chunk []byte, final bool) {
+	if sw.counter == 0 {
+		// waits for the key before it's shared with the workers
+		sw.aead.getAead()
+		go sw.writeFrames()
+	}
+
+	idx := sw.counter
+	encrypted := make(chan []byte, 1)
+	sw.counter += 1
+	sw.workers <- struct{}{}
+
+	go func() {
+		encrypted <- sw.aead.encrypt(chunk, idx, final)
+		<-sw.workers
+	}()
+
+	sw.queue <- encrypted
+}
+
+// writes the queued chunks in order as they get encrypted, the first error is returned by the
+// following writes, so the input stops being archived
+func (sw *safelockWriter) writeFrames() {
+	var err error
+
+	for encrypted := range sw.queue {
+		chunk := <-encrypted
+
+		if err == nil {
+			err = sw.writeFrame(chunk)
+		}
+	}
+
+	sw.written <- err
+}
+
+func (sw *safelockWriter) writeFrame(encrypted []byte) (err error) {
+	var written int
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(encrypted)))
+
+	if written, err = sw.writer.Write(append(frame, encrypted...)); err != nil {
 		err = fmt.Errorf("can't write encrypted chunk > %w", err)
-		return written, sw.handleErr(err)
+		return sw.setErr(err)
 	}
 
-	sw.outputSize += written
-	sw.blocks = append(sw.blocks, fmt.Sprintf("%d", written))
+	sw.increaseOutputSize(written)
+	sw.blocks = append(sw.blocks, fmt.Sprintf("%d", len(encrypted)))
 
 	return
 }
 
+// writes the final chunk and the end of chunks frame, followed by the encrypted header
+// listing all chunk sizes along with the metadata
 func (sw *safelockWriter) WriteHeader() (err error) {
-	if 0 >= sw.outputSize {
+	var headerBytes []byte
+	var header fileHeader
+
+	// empty inputs still get a final chunk, so truncated ones can be told apart
+	sw.submit(sw.pending, true)
+	sw.close()
+
+	if err = <-sw.written; err != nil {
 		return
 	}
 
 	sw.setHeaderSize()
+	header.Blocks = strings.Join(sw.blocks, ";")
 
-	header := "BS;" + strings.Join(sw.blocks, ";")
-	headerBytes := make([]byte, sw.headerSize)
-	headerBytes = append([]byte(header), headerBytes[len(header):]...)
+	if sw.aead.config.Metadata != nil {
+		header.Metadata = *sw.aead.config.Metadata
+	}
 
-	if _, err = sw.writer.Write(headerBytes); err != nil {
+	if headerBytes, err = sealHeader(sw.aead.headerKey, sw.aead.preamble, header, sw.headerSize); err != nil {
+		return sw.handleErr(err)
+	}
+
+	endFrame := make([]byte, frameSizeLength)
+
+	if _, err = sw.writer.Write(append(endFrame, headerBytes...)); err != nil {
 		err = fmt.Errorf("can't write header bytes > %w", err)
 		return sw.handleErr(err)
 	}
@@ -63,14 +170,12 @@ func (sw *safelockWriter) WriteHeader() (err error) {
 	return
 }
 
-func (sw *safelockWriter) setHeaderSize() {
-	ratio := sw.aead.config.HeaderRatio
-	size := sw.outputSize / ratio
-
-	if ratio > size {
-		sw.headerSize = ratio
-		return
-	}
+// closes the queue, so the queued chunks get written and the frames writer stops,
+// safe to call more than once and on every exit path
+func (sw *safelockWriter) close() {
+	sw.closeQueue.Do(func() { close(sw.queue) })
+}
 
-	sw.headerSize = (sw.outputSize + size) / ratio
+func (sw *safelockWriter) setHeaderSize() {
+	sw.headerSize = getPaddedHeaderSize(sw.getOutputSize(), sw.aead.config.HeaderRatio)
 }
@@ -0,0 +1,18 @@
+package slErrs
+
+import "fmt"
+
+// decrypted file already exists in the output path
+type ErrFileConflict struct {
+	BaseError,
+	Path string
+}
+
+func (e *ErrFileConflict) Error() string {
+	return fmt.Sprintf("decrypted file already exists (%s)", e.Path)
+}
+
+func (e *ErrFileConflict) Is(t error) bool {
+	_, ok := t.(*ErrFileConflict)
+	return ok
+}
@@ -14,6 +14,6 @@ func (e *BaseError) Error() string {
 
 // check if error or unwrapped error  matches target
 func Is[T error](err error) bool {
-	target, ok := err.(T)
-	return ok && errors.Is(err, target) || errors.Is(errors.Unwrap(err), target)
+	target, _ := err.(T)
+	return errors.Is(err, target) || errors.Is(errors.Unwrap(err), target)
 }
@@ -0,0 +1,24 @@
+package slErrs
+
+import "fmt"
+
+// the password, keyfile or identities don't unlock the encrypted file, unlike other
+// [ErrFailedToAuthenticate] errors nothing is decrypted yet, so it's safe to retry with others
+type ErrNoMatchingKey struct {
+	BaseError,
+	Msg string
+}
+
+func (e *ErrNoMatchingKey) Error() string {
+	return fmt.Sprintf("invalid password or corrupted encryption > %s", e.Msg)
+}
+
+// also matches [ErrFailedToAuthenticate], which it's a case of
+func (e *ErrNoMatchingKey) Is(t error) bool {
+	switch t.(type) {
+	case *ErrNoMatchingKey, *ErrFailedToAuthenticate:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,18 @@
+package slErrs
+
+import "fmt"
+
+// encrypted input ends before its final chunk, it was either cut short or had chunks removed
+type ErrTruncatedInput struct {
+	BaseError,
+	Chunk int
+}
+
+func (e *ErrTruncatedInput) Error() string {
+	return fmt.Sprintf("encrypted input is truncated at chunk (%d)", e.Chunk)
+}
+
+func (e *ErrTruncatedInput) Is(t error) bool {
+	_, ok := t.(*ErrTruncatedInput)
+	return ok
+}
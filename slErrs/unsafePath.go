@@ -0,0 +1,19 @@
+package slErrs
+
+import "fmt"
+
+// archive entry path that would be extracted outside the output path
+type ErrUnsafePath struct {
+	BaseError,
+	Path string
+	Reason string
+}
+
+func (e *ErrUnsafePath) Error() string {
+	return fmt.Sprintf("unsafe archive path (%s) > %s", e.Path, e.Reason)
+}
+
+func (e *ErrUnsafePath) Is(t error) bool {
+	_, ok := t.(*ErrUnsafePath)
+	return ok
+}
@@ -0,0 +1,28 @@
+package slErrs
+
+import "fmt"
+
+// encrypted file format version is not supported
+type ErrUnsupportedVersion struct {
+	BaseError,
+	Version uint8
+	Oldest uint8
+	Latest uint8
+}
+
+func (e *ErrUnsupportedVersion) Error() string {
+	if e.Version < e.Oldest {
+		return fmt.Sprintf(
+			"unsupported file format version (%d) of a pre-release build, oldest supported (%d)",
+			e.Version,
+			e.Oldest,
+		)
+	}
+
+	return fmt.Sprintf("unsupported file format version (%d) latest supported (%d)", e.Version, e.Latest)
+}
+
+func (e *ErrUnsupportedVersion) Is(t error) bool {
+	_, ok := t.(*ErrUnsupportedVersion)
+	return ok
+}
@@ -0,0 +1,18 @@
+package slErrs
+
+import "fmt"
+
+// password rejected by the password policy
+type ErrWeakPassword struct {
+	BaseError,
+	Reason string
+}
+
+func (e *ErrWeakPassword) Error() string {
+	return fmt.Sprintf("weak password > %s", e.Reason)
+}
+
+func (e *ErrWeakPassword) Is(t error) bool {
+	_, ok := t.(*ErrWeakPassword)
+	return ok
+}
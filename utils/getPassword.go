@@ -5,30 +5,138 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
+	"runtime"
 
+	"github.com/mrf345/safelock-cli/passwords"
 	slErrs "github.com/mrf345/safelock-cli/slErrs"
+	"golang.org/x/term"
 )
 
-// get the password from pipe or ask the user to enter it
-func GetPassword(length int) (password string, err error) {
-	pipeInfo, _ := os.Stdin.Stat()
+// environment variable the password can be passed through instead of stdin
+const PasswordEnv = "SAFELOCK_PASSWORD"
 
-	hasPipe := !strings.HasPrefix(pipeInfo.Mode().String(), "Dcr")
+// environment variable a new password can be passed through instead of the terminal
+const NewPasswordEnv = "SAFELOCK_NEW_PASSWORD"
 
-	if !hasPipe {
-		fmt.Printf("Enter password (minimum of %d chanters): ", length)
+// get the password from environment variable, pipe or ask the user to enter it,
+// and to enter it again if `confirm` and it's entered through the terminal
+func GetPassword(length int, confirm bool) (password string, err error) {
+	if password, ok := os.LookupEnv(PasswordEnv); ok {
+		return checkPassword(password, length)
 	}
 
-	if password, err = bufio.NewReader(os.Stdin).ReadString('\n'); err != nil && err != io.EOF {
+	return readPassword(os.Stdin, "Enter password", length, confirm)
+}
+
+// checks if the password is asked for through the terminal, rather than passed
+func IsPasswordPrompted() bool {
+	_, hasEnv := os.LookupEnv(PasswordEnv)
+	return !hasEnv && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// get the password from `source`, which must be set
+func GetSourcePassword(source passwords.Source, length int) (password string, err error) {
+	if password, err = source.Read(); err != nil {
 		return
 	}
 
-	password = strings.TrimSpace(password)
+	return checkPassword(password, length)
+}
 
-	if len(password) < length {
-		err = &slErrs.ErrInvalidPassword{Len: len(password), Need: length}
+// get the password from environment variable or ask the user to enter it through
+// the terminal, leaving stdin free for data, and to enter it again if `confirm`
+func GetTerminalPassword(length int, confirm bool) (password string, err error) {
+	var terminal *os.File
+
+	if password, ok := os.LookupEnv(PasswordEnv); ok {
+		return checkPassword(password, length)
+	}
+
+	if terminal, err = os.Open(getTerminalPath()); err != nil {
+		err = fmt.Errorf("failed to open terminal to read password > %w", err)
+		return
+	}
+	defer terminal.Close()
+
+	return readPassword(terminal, "Enter password", length, confirm)
+}
+
+// get a new password from environment variable or ask the user to enter it through the terminal,
+// leaving stdin free for the current password
+func GetNewPassword(length int) (password string, err error) {
+	var terminal *os.File
+
+	if password, ok := os.LookupEnv(NewPasswordEnv); ok {
+		return checkPassword(password, length)
+	}
+
+	if terminal, err = os.Open(getTerminalPath()); err != nil {
+		err = fmt.Errorf("failed to open terminal to read new password > %w", err)
+		return
+	}
+	defer terminal.Close()
+
+	return readPassword(terminal, "Enter new password", length, true)
+}
+
+func getTerminalPath() string {
+	if runtime.GOOS == "windows" {
+		return "CONIN$"
+	}
+
+	return "/dev/tty"
+}
+
+// reads the password without echoing it if `input` is a terminal, otherwise reads its first line
+func readPassword(input *os.File, prompt string, length int, confirm bool) (password string, err error) {
+	if !term.IsTerminal(int(input.Fd())) {
+		if password, err = bufio.NewReader(input).ReadString('\n'); err != nil && err != io.EOF {
+			return
+		}
+
+		return checkPassword(passwords.TrimLineEnding(password), length)
+	}
+
+	// stdout could be used for data, so prompt through stderr
+	if password, err = promptPassword(input, fmt.Sprintf("%s (minimum of %d chanters): ", prompt, length)); err != nil {
+		return
+	}
+
+	if password, err = checkPassword(password, length); err != nil || !confirm {
+		return
+	}
+
+	var confirmation string
+
+	if confirmation, err = promptPassword(input, "Confirm password: "); err != nil {
+		return
+	}
+
+	if confirmation != password {
+		err = fmt.Errorf("passwords do not match")
 	}
 
 	return
 }
+
+func promptPassword(terminal *os.File, prompt string) (password string, err error) {
+	var input []byte
+
+	fmt.Fprint(os.Stderr, prompt)
+	input, err = term.ReadPassword(int(terminal.Fd()))
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read password > %w", err)
+	}
+
+	return passwords.TrimLineEnding(string(input)), nil
+}
+
+func checkPassword(password string, length int) (string, error) {
+	if len(password) < length {
+		return password, &slErrs.ErrInvalidPassword{Len: len(password), Need: length}
+	}
+
+	return password, nil
+}
@@ -5,15 +5,19 @@ import (
 	"os"
 
 	"github.com/inancgumus/screen"
+	"golang.org/x/term"
 )
 
 // pretty print error and change exit status
 func PrintErrsAndExit(errs ...string) {
-	screen.Clear()
-	screen.MoveTopLeft()
+	// stdout can be the encrypted or decrypted data stream, which escape codes would corrupt
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		screen.Clear()
+		screen.MoveTopLeft()
+	}
 
 	for _, e := range errs {
-		fmt.Println(e)
+		fmt.Fprintln(os.Stderr, e)
 	}
 	os.Exit(1)
 }
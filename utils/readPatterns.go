@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// reads glob patterns from `path` one per line, skipping empty lines and `#` comments
+func ReadPatterns(path string) (patterns []string, err error) {
+	var content []byte
+
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+
+	return
+}